@@ -32,6 +32,7 @@ package gmf
 
 /*
 #cgo pkg-config: libavutil
+#include <errno.h>
 #include <libavutil/audio_fifo.h>
 #include <libavutil/frame.h>
 
@@ -45,56 +46,153 @@ int read_fifo(AVAudioFifo* fifo, AVFrame* frame, int nb_samples){
 */
 import "C"
 
-type AVAudioFifo struct {
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Buffers samples between decoder and encoder, which requires frames of fixed size,
+// e.g. 1024 samples for AAC. It replaces AVAudioFifo, methods of which don't report errors.
+type AudioFifo struct {
 	avAudioFifo  *C.struct_AVAudioFifo
 	sampleFormat int32
 	channels     int
+	CgoMemoryManage
 }
 
-func NewAVAudioFifo(sampleFormat int32, channels int, nb_samples int) *AVAudioFifo {
-	fifo := C.av_audio_fifo_alloc(sampleFormat, C.int(channels), C.int(nb_samples))
+// Creates fifo with initial capacity of nbSamples, it grows on Write if it's needed.
+func NewAudioFifo(sampleFormat int32, channels int, nbSamples int) (*AudioFifo, error) {
+	fifo := C.av_audio_fifo_alloc(sampleFormat, C.int(channels), C.int(nbSamples))
 	if fifo == nil {
-		return nil
+		return nil, errors.New(fmt.Sprintf("Unable to allocate audio fifo for %d channels of %s", channels, GetSampleFmtName(sampleFormat)))
+	}
+
+	return &AudioFifo{avAudioFifo: fifo, sampleFormat: sampleFormat, channels: channels}, nil
+}
+
+// Returns number of buffered samples.
+func (this *AudioFifo) Size() int {
+	if this.avAudioFifo == nil {
+		return 0
+	}
+
+	return int(C.av_audio_fifo_size(this.avAudioFifo))
+}
+
+// Appends all samples of f. Frame should have the same sample format and channels number.
+func (this *AudioFifo) Write(f *Frame) error {
+	if this.avAudioFifo == nil {
+		return ErrNilContext
+	}
+
+	if f.Format() != this.sampleFormat || f.Channels() != this.channels {
+		return errors.New(fmt.Sprintf("Unable to write frame of %d channels of %s to audio fifo of %d channels of %s",
+			f.Channels(), GetSampleFmtName(f.Format()), this.channels, GetSampleFmtName(this.sampleFormat)))
+	}
+
+	if ret := this.write(f); ret < f.NbSamples() {
+		if ret < 0 {
+			return errors.New(fmt.Sprintf("Unable to write to audio fifo: %s", AvError(ret)))
+		}
+
+		return errors.New(fmt.Sprintf("Unable to write to audio fifo: %d of %d samples written", ret, f.NbSamples()))
+	}
+
+	return nil
+}
+
+// Returns new frame with at most nbSamples samples, io.EOF if fifo is empty.
+func (this *AudioFifo) Read(nbSamples int) (*Frame, error) {
+	if this.avAudioFifo == nil {
+		return nil, ErrNilContext
+	}
+
+	size := this.Size()
+	if size == 0 {
+		return nil, io.EOF
+	}
+
+	if nbSamples < size {
+		size = nbSamples
+	}
+
+	frame, err := NewAudioFrame(this.sampleFormat, this.channels, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret := int(C.read_fifo(this.avAudioFifo, frame.avFrame, C.int(size))); ret != size {
+		Release(frame)
+
+		if ret < 0 {
+			return nil, errors.New(fmt.Sprintf("Unable to read from audio fifo: %s", AvError(ret)))
+		}
+
+		return nil, errors.New(fmt.Sprintf("Unable to read from audio fifo: %d of %d samples read", ret, size))
 	}
 
-	return &AVAudioFifo{
-		avAudioFifo:  fifo,
-		sampleFormat: sampleFormat,
-		channels:     channels,
+	return frame, nil
+}
+
+func (this *AudioFifo) write(f *Frame) int {
+	return int(C.write_fifo(this.avAudioFifo, f.avFrame, C.int(f.NbSamples())))
+}
+
+func (this *AudioFifo) Free() {
+	if this.avAudioFifo == nil {
+		return
 	}
+
+	C.av_audio_fifo_free(this.avAudioFifo)
+	this.avAudioFifo = nil
+}
+
+// Deprecated: use AudioFifo, which reports errors and checks frame format.
+type AVAudioFifo struct {
+	fifo *AudioFifo
+}
+
+func NewAVAudioFifo(sampleFormat int32, channels int, nb_samples int) *AVAudioFifo {
+	fifo, err := NewAudioFifo(sampleFormat, channels, nb_samples)
+	if err != nil {
+		return nil
+	}
+
+	return &AVAudioFifo{fifo: fifo}
 }
 
 func (this *AVAudioFifo) SamplesToRead() int {
-	return int(C.av_audio_fifo_size(this.avAudioFifo))
+	return this.fifo.Size()
 }
+
 func (this *AVAudioFifo) SamplesCanWrite() int {
-	return int(C.av_audio_fifo_space(this.avAudioFifo))
+	if this.fifo.avAudioFifo == nil {
+		return 0
+	}
+
+	return int(C.av_audio_fifo_space(this.fifo.avAudioFifo))
 }
+
+// Returns number of written samples or negative AVERROR.
 func (this *AVAudioFifo) Write(frame *Frame) int {
-	return int(C.write_fifo(this.avAudioFifo,
-		frame.avFrame,
-		C.int(frame.NbSamples())))
+	if this.fifo.avAudioFifo == nil {
+		return -int(C.EINVAL)
+	}
+
+	return this.fifo.write(frame)
 }
+
+// Returns nil if fifo is empty or reading fails.
 func (this *AVAudioFifo) Read(sampleCount int) *Frame {
-	rsize := this.SamplesToRead()
-	size := rsize
-	if sampleCount <= rsize {
-		size = sampleCount
-	}
-	frame, err := NewAudioFrame(this.sampleFormat, this.channels, size)
-	if frame == nil || err != nil {
-		return nil
-	}
-	if int(C.read_fifo(this.avAudioFifo,
-		frame.avFrame,
-		C.int(size))) == size {
-		return frame
-	} else {
-		frame.Release()
+	frame, err := this.fifo.Read(sampleCount)
+	if err != nil {
 		return nil
 	}
+
+	return frame
 }
 
 func (this *AVAudioFifo) Free() {
-	C.av_audio_fifo_free(this.avAudioFifo)
+	this.fifo.Free()
 }
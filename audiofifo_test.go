@@ -0,0 +1,80 @@
+package gmf
+
+import (
+	"io"
+	"testing"
+)
+
+func TestAudioFifo(t *testing.T) {
+	fifo, err := NewAudioFifo(AV_SAMPLE_FMT_S16, 2, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(fifo)
+
+	for i := 0; i < 2; i++ {
+		frame := assert(NewAudioFrame(AV_SAMPLE_FMT_S16, 2, 700)).(*Frame)
+
+		if err := fifo.Write(frame); err != nil {
+			t.Fatal(err)
+		}
+		Release(frame)
+	}
+
+	if fifo.Size() != 1400 {
+		t.Fatalf("Expected 1400 samples, %d got\n", fifo.Size())
+	}
+
+	for _, expected := range []int{1024, 376} {
+		frame, err := fifo.Read(1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if frame.NbSamples() != expected {
+			t.Fatalf("Expected %d samples, %d got\n", expected, frame.NbSamples())
+		}
+		Release(frame)
+	}
+
+	if _, err := fifo.Read(1024); err != io.EOF {
+		t.Fatalf("Expected io.EOF, '%v' got\n", err)
+	}
+
+	frame := assert(NewAudioFrame(AV_SAMPLE_FMT_S16P, 2, 100)).(*Frame)
+	defer Release(frame)
+
+	if err := fifo.Write(frame); err == nil {
+		t.Fatal("Expected error for frame of other sample format")
+	}
+}
+
+func TestAVAudioFifo(t *testing.T) {
+	fifo := NewAVAudioFifo(AV_SAMPLE_FMT_S16, 2, 512)
+	if fifo == nil {
+		t.Fatal("Unable to allocate audio fifo")
+	}
+
+	frame := assert(NewAudioFrame(AV_SAMPLE_FMT_S16, 2, 700)).(*Frame)
+	defer Release(frame)
+
+	if wrote := fifo.Write(frame); wrote != 700 {
+		t.Fatalf("Expected 700 samples written, %d got\n", wrote)
+	}
+
+	if fifo.SamplesToRead() != 700 {
+		t.Fatalf("Expected 700 samples to read, %d got\n", fifo.SamplesToRead())
+	}
+
+	out := fifo.Read(1024)
+	if out == nil || out.NbSamples() != 700 {
+		t.Fatalf("Expected frame of 700 samples, %v got\n", out)
+	}
+	Release(out)
+
+	fifo.Free()
+
+	if fifo.SamplesToRead() != 0 || fifo.Write(frame) >= 0 {
+		t.Fatal("Expected freed fifo to be empty and not writable")
+	}
+}
@@ -22,11 +22,16 @@ import "C"
 import (
 	"errors"
 	"fmt"
+	"io"
 	"unsafe"
 )
 
 var (
 	IO_BUFFER_SIZE int = 32768
+
+	// Seek handler is called with this whence value, when the library wants
+	// to know the size of the stream. Return negative value if it's unknown.
+	AVSEEK_SIZE int = C.AVSEEK_SIZE
 )
 
 // Functions prototypes for custom IO. Implement necessary prototypes and pass instance pointer to NewAVIOContext.
@@ -38,10 +43,16 @@ var (
 //	}
 //
 //	avoictx := NewAVIOContext(ctx, &AVIOHandlers{ReadPacket: gridFsReader})
+//
+// Seek receives io.Seek* compatible whence or AVSEEK_SIZE. If Seek is nil,
+// the context is non-seekable.
 type AVIOHandlers struct {
 	ReadPacket  func() ([]byte, int)
 	WritePacket func([]byte)
 	Seek        func(int64, int) int64
+
+	// used instead of WritePacket, if set, reports write errors to the library
+	write func([]byte) error
 }
 
 // Global map of AVIOHandlers
@@ -77,29 +88,102 @@ func NewAVIOContext(ctx *FmtCtx, handlers *AVIOHandlers) (*AVIOContext, error) {
 		this.handlerKey = uintptr(unsafe.Pointer(ctx.avCtx))
 	}
 
+	if handlers == nil {
+		handlers = &AVIOHandlers{}
+	}
+
 	if handlers.ReadPacket != nil {
 		ptrRead = (*[0]byte)(C.readCallBack)
 	}
 
-	if handlers.WritePacket != nil {
+	writeFlag := 0
+
+	if handlers.WritePacket != nil || handlers.write != nil {
 		ptrWrite = (*[0]byte)(C.writeCallBack)
+		writeFlag = 1
 	}
 
 	if handlers.Seek != nil {
 		ptrSeek = (*[0]byte)(C.seekCallBack)
 	}
 
-	if this.avAVIOContext = C.avio_alloc_context(buffer, C.int(IO_BUFFER_SIZE), 0, unsafe.Pointer(ctx.avCtx), ptrRead, ptrWrite, ptrSeek); this.avAVIOContext == nil {
+	if this.avAVIOContext = C.avio_alloc_context(buffer, C.int(IO_BUFFER_SIZE), C.int(writeFlag), unsafe.Pointer(ctx.avCtx), ptrRead, ptrWrite, ptrSeek); this.avAVIOContext == nil {
 		return nil, errors.New("unable to initialize avio context")
 	}
 
 	return this, nil
 }
 
+// Wraps io.Reader into ReadPacket handler. Short reads are passed as is,
+// end of stream is reported to the library as AVERROR_EOF.
+func readerHandler(r io.Reader) func() ([]byte, int) {
+	b := make([]byte, IO_BUFFER_SIZE)
+
+	return func() ([]byte, int) {
+		for {
+			n, err := r.Read(b)
+			if n > 0 {
+				return b, n
+			}
+
+			if err == io.EOF {
+				return b, AVERROR_EOF
+			}
+
+			if err != nil {
+				return b, int(C.AVERROR_UNKNOWN)
+			}
+		}
+	}
+}
+
+// Wraps io.Writer into write handler.
+func writerHandler(w io.Writer) func([]byte) error {
+	return func(b []byte) error {
+		_, err := w.Write(b)
+		return err
+	}
+}
+
+// Wraps io.Seeker into Seek handler.
+func seekerHandler(s io.Seeker) func(int64, int) int64 {
+	return func(offset int64, whence int) int64 {
+		if whence == AVSEEK_SIZE {
+			cur, err := s.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return -1
+			}
+
+			size, err := s.Seek(0, io.SeekEnd)
+			if err != nil {
+				return -1
+			}
+
+			if _, err := s.Seek(cur, io.SeekStart); err != nil {
+				return -1
+			}
+
+			return size
+		}
+
+		pos, err := s.Seek(offset, whence)
+		if err != nil {
+			return -1
+		}
+
+		return pos
+	}
+}
+
 func (this *AVIOContext) Free() {
+	if this.avAVIOContext == nil {
+		return
+	}
+
 	delete(handlersMap, this.handlerKey)
 	C.av_free(unsafe.Pointer(this.avAVIOContext.buffer))
 	C.av_free(unsafe.Pointer(this.avAVIOContext))
+	this.avAVIOContext = nil
 }
 
 //export readCallBack
@@ -114,7 +198,7 @@ func readCallBack(opaque unsafe.Pointer, buf *C.uint8_t, buf_size C.int) C.int {
 	}
 
 	b, n := handlers.ReadPacket()
-	if n >= 0 {
+	if n > 0 {
 		C.memcpy(unsafe.Pointer(buf), unsafe.Pointer(&b[0]), C.size_t(n))
 	}
 
@@ -128,6 +212,14 @@ func writeCallBack(opaque unsafe.Pointer, buf *C.uint8_t, buf_size C.int) C.int
 		panic(fmt.Sprintf("No handlers instance found, according pointer: %v", opaque))
 	}
 
+	if handlers.write != nil {
+		if err := handlers.write(C.GoBytes(unsafe.Pointer(buf), buf_size)); err != nil {
+			return C.AVERROR_UNKNOWN
+		}
+
+		return buf_size
+	}
+
 	if handlers.WritePacket == nil {
 		panic("No writer handler initialized.")
 	}
@@ -147,5 +239,6 @@ func seekCallBack(opaque unsafe.Pointer, offset C.int64_t, whence C.int) C.int64
 		panic("No seek handler initialized.")
 	}
 
-	return C.int64_t(handlers.Seek(int64(offset), int(whence)))
+	// AVSEEK_FORCE is just a hint, handlers shouldn't care about it
+	return C.int64_t(handlers.Seek(int64(offset), int(whence&^C.AVSEEK_FORCE)))
 }
@@ -0,0 +1,104 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavcodec
+
+#include <stdlib.h>
+#include "libavcodec/avcodec.h"
+
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// Bitstream filter, e.g. "h264_mp4toannexb" to remux H.264 from MP4 into MPEG-TS.
+type BitstreamFilter struct {
+	avBSFCtx *C.struct_AVBSFContext
+	CgoMemoryManage
+}
+
+// Creates bitstream filter by name, input parameters are taken from cc.
+func NewBitstreamFilter(name string, cc *CodecCtx) (*BitstreamFilter, error) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	filter := C.av_bsf_get_by_name(cname)
+	if filter == nil {
+		return nil, errors.New(fmt.Sprintf("Unknown bitstream filter '%s'", name))
+	}
+
+	this := &BitstreamFilter{}
+
+	if averr := C.av_bsf_alloc(filter, &this.avBSFCtx); averr < 0 {
+		return nil, errors.New(fmt.Sprintf("Unable to allocate bitstream filter '%s': %s", name, AvError(int(averr))))
+	}
+
+	if averr := C.avcodec_parameters_from_context(this.avBSFCtx.par_in, cc.avCodecCtx); averr < 0 {
+		Release(this)
+		return nil, errors.New(fmt.Sprintf("Unable to copy codec parameters: %s", AvError(int(averr))))
+	}
+
+	this.avBSFCtx.time_base_in = cc.avCodecCtx.time_base
+
+	if averr := C.av_bsf_init(this.avBSFCtx); averr < 0 {
+		Release(this)
+		return nil, errors.New(fmt.Sprintf("Unable to initialize bitstream filter '%s': %s", name, AvError(int(averr))))
+	}
+
+	return this, nil
+}
+
+func (this *BitstreamFilter) Name() string {
+	return C.GoString(this.avBSFCtx.filter.name)
+}
+
+// Sends packet into the filter. Filter takes the packet data, so p is blank after the call,
+// but still should be released by caller. Nil packet signals the end of stream.
+func (this *BitstreamFilter) SendPacket(p *Packet) error {
+	var avPacket *C.struct_AVPacket
+
+	if p != nil {
+		avPacket = &p.avPacket
+	}
+
+	if averr := C.av_bsf_send_packet(this.avBSFCtx, avPacket); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to send packet to bitstream filter: %s", AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// Returns filtered packet. Nil packet and nil error mean, that filter needs more input,
+// io.EOF is returned when filter is drained.
+func (this *BitstreamFilter) ReceivePacket() (*Packet, error) {
+	p := NewPacket()
+
+	if averr := int(C.av_bsf_receive_packet(this.avBSFCtx, &p.avPacket)); averr < 0 {
+		if averr == AVERROR_EAGAIN {
+			return nil, nil
+		}
+
+		if averr == AVERROR_EOF {
+			return nil, io.EOF
+		}
+
+		return nil, errors.New(fmt.Sprintf("Unable to receive packet from bitstream filter: %s", AvError(averr)))
+	}
+
+	return p, nil
+}
+
+// Time base of output packets.
+func (this *BitstreamFilter) TimeBase() AVRational {
+	return AVRational(this.avBSFCtx.time_base_out)
+}
+
+func (this *BitstreamFilter) Free() {
+	C.av_bsf_free(&this.avBSFCtx)
+}
@@ -0,0 +1,47 @@
+package gmf
+
+import (
+	"log"
+	"testing"
+)
+
+func TestBitstreamFilter(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
+
+	if _, err := NewBitstreamFilter("not_existing_bsf", ist.CodecCtx()); err == nil {
+		t.Fatal("Expected error for unknown filter name")
+	}
+
+	bsf, err := NewBitstreamFilter("null", ist.CodecCtx())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(bsf)
+
+	packet := inputCtx.GetNextPacket()
+	size := packet.Size()
+
+	if err := bsf.SendPacket(packet); err != nil {
+		t.Fatal(err)
+	}
+	Release(packet)
+
+	out, err := bsf.ReceivePacket()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out == nil || out.Size() != size {
+		t.Fatal("Expected the same packet from null filter")
+	}
+
+	log.Println("Packet has been filtered by", bsf.Name())
+	Release(out)
+}
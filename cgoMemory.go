@@ -1,10 +1,40 @@
 package gmf
 
 import (
+	"errors"
 	"log"
+	"runtime"
 	"sync/atomic"
 )
 
+// Returned by methods called on a wrapper, which underlying C object is not allocated or is already freed.
+var ErrNilContext = errors.New("context is not initialized or already freed")
+
+var autoRelease int32
+
+// Enables finalizers, which free Frame, Packet and CodecCtx objects, when they are garbage
+// collected without Release. It affects only objects created after the call. Finalizers
+// are non-deterministic, so explicit Release is still preferred.
+func SetAutoRelease(enable bool) {
+	if enable {
+		atomic.StoreInt32(&autoRelease, 1)
+	} else {
+		atomic.StoreInt32(&autoRelease, 0)
+	}
+}
+
+func IsAutoRelease() bool {
+	return atomic.LoadInt32(&autoRelease) == 1
+}
+
+func setAutoRelease(i CgoMemoryManager) {
+	if IsAutoRelease() {
+		runtime.SetFinalizer(i, func(i CgoMemoryManager) {
+			i.Free()
+		})
+	}
+}
+
 type CgoMemoryManage struct {
 	retainCount int32
 }
@@ -22,6 +52,8 @@ func Retain(i CgoMemoryManager) CgoMemoryManager {
 	return i
 }
 
+// Decrements retain count and frees i, when it reaches zero. Free implementations
+// are idempotent, so releasing already freed object is safe.
 func Release(i CgoMemoryManager) {
 	if nil == i {
 		return
@@ -1,8 +1,11 @@
 package gmf
 
 import (
+	"context"
 	//	"log"
+	"runtime"
 	"testing"
+	"time"
 )
 
 type SubData struct {
@@ -68,3 +71,124 @@ func TestCgoMemory(t *testing.T) {
 		t.Fatal("subData not run Free.")
 	}
 }
+
+func TestReleaseTwice(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	inputCtx.CloseInputAndRelease()
+	inputCtx.CloseInputAndRelease()
+	inputCtx.Free()
+
+	if err := inputCtx.SeekFrameAt(0, 0); err != ErrNilContext {
+		t.Fatalf("Expected ErrNilContext, '%v' got\n", err)
+	}
+
+	if _, err := inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO); err != ErrNilContext {
+		t.Fatalf("Expected ErrNilContext, '%v' got\n", err)
+	}
+
+	if p := inputCtx.GetNextPacket(); p != nil || inputCtx.Err() != ErrNilContext {
+		t.Fatalf("Expected no packet and ErrNilContext, '%v' got\n", inputCtx.Err())
+	}
+
+	for p := range inputCtx.GetNewPacketsWithContext(context.Background()) {
+		Release(p)
+		t.Fatal("Expected no packets from released context")
+	}
+
+	if inputCtx.Err() != ErrNilContext {
+		t.Fatalf("Expected ErrNilContext, '%v' got\n", inputCtx.Err())
+	}
+
+	md := inputCtx.Metadata()
+	Release(md)
+
+	inputCtx.SetProbeSize(1024).SetFlag(AVFMT_FLAG_GENPTS)
+
+	if inputCtx.Duration() != 0 || inputCtx.StartTime() != 0 || len(inputCtx.StreamsOfType(AVMEDIA_TYPE_VIDEO)) != 0 || inputCtx.DumpFormatString() != "" {
+		t.Fatal("Expected zero values for released context")
+	}
+
+	frame := NewFrame()
+	Release(frame)
+	Release(frame)
+
+	cc := NewCodecCtx(assert(FindEncoder("mpeg4")).(*Codec))
+	Release(cc)
+	Release(cc)
+
+	if err := cc.Open(nil); err != ErrNilContext {
+		t.Fatalf("Expected ErrNilContext, '%v' got\n", err)
+	}
+}
+
+type autoReleased struct {
+	CgoMemoryManage
+	freed chan bool
+}
+
+func (this *autoReleased) Free() {
+	this.freed <- true
+}
+
+// Runs GC until freed is signaled by finalizer or timeout is reached.
+func waitFinalizer(freed chan bool) bool {
+	for i := 0; i < 50; i++ {
+		runtime.GC()
+
+		select {
+		case <-freed:
+			return true
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	return false
+}
+
+func TestAutoRelease(t *testing.T) {
+	SetAutoRelease(true)
+	defer SetAutoRelease(false)
+
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	md := inputCtx.Metadata()
+	defer Release(md)
+
+	freed := make(chan bool, 2)
+	setAutoRelease(&autoReleased{freed: freed})
+
+	for i := 0; i < 100; i++ {
+		NewFrame()
+		NewPacket()
+	}
+
+	if !waitFinalizer(freed) {
+		t.Fatal("Expected finalizer to free unreleased object")
+	}
+
+	select {
+	case <-freed:
+		t.Fatal("Expected object to be freed once")
+	default:
+	}
+
+	// finalizer calls Free of released frame again, it should be no-op
+	frame := NewFrame()
+	Release(frame)
+
+	if frame.avFrame != nil {
+		t.Fatal("Expected released frame to be freed")
+	}
+
+	frame.Free()
+
+	if frame.avFrame != nil {
+		t.Fatal("Expected frame to stay freed")
+	}
+
+	// wrappers without finalizers aren't freed by GC
+	if inputCtx.StreamsCnt() == 0 || len(md.Pairs()) == 0 {
+		t.Fatal("Expected context and metadata not to be freed by GC")
+	}
+}
@@ -0,0 +1,70 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavutil
+
+#include <stdlib.h>
+
+#include "libavutil/channel_layout.h"
+
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+var (
+	AV_CH_LAYOUT_MONO              int64 = C.AV_CH_LAYOUT_MONO
+	AV_CH_LAYOUT_STEREO            int64 = C.AV_CH_LAYOUT_STEREO
+	AV_CH_LAYOUT_2POINT1           int64 = C.AV_CH_LAYOUT_2POINT1
+	AV_CH_LAYOUT_2_1               int64 = C.AV_CH_LAYOUT_2_1
+	AV_CH_LAYOUT_SURROUND          int64 = C.AV_CH_LAYOUT_SURROUND
+	AV_CH_LAYOUT_3POINT1           int64 = C.AV_CH_LAYOUT_3POINT1
+	AV_CH_LAYOUT_4POINT0           int64 = C.AV_CH_LAYOUT_4POINT0
+	AV_CH_LAYOUT_4POINT1           int64 = C.AV_CH_LAYOUT_4POINT1
+	AV_CH_LAYOUT_2_2               int64 = C.AV_CH_LAYOUT_2_2
+	AV_CH_LAYOUT_QUAD              int64 = C.AV_CH_LAYOUT_QUAD
+	AV_CH_LAYOUT_5POINT0           int64 = C.AV_CH_LAYOUT_5POINT0
+	AV_CH_LAYOUT_5POINT1           int64 = C.AV_CH_LAYOUT_5POINT1
+	AV_CH_LAYOUT_5POINT0_BACK      int64 = C.AV_CH_LAYOUT_5POINT0_BACK
+	AV_CH_LAYOUT_5POINT1_BACK      int64 = C.AV_CH_LAYOUT_5POINT1_BACK
+	AV_CH_LAYOUT_6POINT0           int64 = C.AV_CH_LAYOUT_6POINT0
+	AV_CH_LAYOUT_6POINT1           int64 = C.AV_CH_LAYOUT_6POINT1
+	AV_CH_LAYOUT_7POINT0           int64 = C.AV_CH_LAYOUT_7POINT0
+	AV_CH_LAYOUT_7POINT1           int64 = C.AV_CH_LAYOUT_7POINT1
+	AV_CH_LAYOUT_7POINT1_WIDE      int64 = C.AV_CH_LAYOUT_7POINT1_WIDE
+	AV_CH_LAYOUT_7POINT1_WIDE_BACK int64 = C.AV_CH_LAYOUT_7POINT1_WIDE_BACK
+	AV_CH_LAYOUT_OCTAGONAL         int64 = C.AV_CH_LAYOUT_OCTAGONAL
+	AV_CH_LAYOUT_STEREO_DOWNMIX    int64 = C.AV_CH_LAYOUT_STEREO_DOWNMIX
+)
+
+// Returns default channel layout for number of channels, 0 if there is no one.
+func ChannelLayoutDefault(channels int) int64 {
+	return int64(C.av_get_default_channel_layout(C.int(channels)))
+}
+
+// Returns number of channels in layout.
+func ChannelLayoutChannels(layout int64) int {
+	return int(C.av_get_channel_layout_nb_channels(C.uint64_t(layout)))
+}
+
+// Returns description of layout, e.g. "stereo" or "5.1".
+func ChannelLayoutName(layout int64) string {
+	size := 256
+	buf := (*C.char)(C.malloc(C.size_t(size)))
+	defer C.free(unsafe.Pointer(buf))
+
+	C.av_get_channel_layout_string(buf, C.int(size), 0, C.uint64_t(layout))
+
+	return C.GoString(buf)
+}
+
+// Returns channel layout by its name, e.g. "stereo" or "5.1", 0 if it's unknown.
+func ChannelLayoutByName(name string) int64 {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	return int64(C.av_get_channel_layout(cname))
+}
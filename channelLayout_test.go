@@ -0,0 +1,23 @@
+package gmf
+
+import (
+	"testing"
+)
+
+func TestChannelLayout(t *testing.T) {
+	if l := ChannelLayoutDefault(2); l != AV_CH_LAYOUT_STEREO {
+		t.Fatalf("Expected stereo layout %d, %d got\n", AV_CH_LAYOUT_STEREO, l)
+	}
+
+	if n := ChannelLayoutChannels(AV_CH_LAYOUT_5POINT1); n != 6 {
+		t.Fatalf("Expected 6 channels, %d got\n", n)
+	}
+
+	if name := ChannelLayoutName(AV_CH_LAYOUT_MONO); name != "mono" {
+		t.Fatalf("Expected 'mono', '%s' got\n", name)
+	}
+
+	if l := ChannelLayoutByName("stereo"); l != AV_CH_LAYOUT_STEREO {
+		t.Fatalf("Expected %d, %d got\n", AV_CH_LAYOUT_STEREO, l)
+	}
+}
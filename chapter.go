@@ -0,0 +1,111 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavformat libavutil
+
+#include <errno.h>
+#include <stdlib.h>
+
+#include "libavformat/avformat.h"
+#include "libavutil/mem.h"
+
+static AVChapter *gmf_get_chapter(AVFormatContext *ctx, int idx) {
+	return ctx->chapters[idx];
+}
+
+static int gmf_add_chapter(AVFormatContext *ctx, int64_t id, AVRational tb, int64_t start, int64_t end, const char *title) {
+	AVChapter *ch;
+	int nb = ctx->nb_chapters;
+
+	if (!(ch = av_mallocz(sizeof(*ch)))) {
+		return AVERROR(ENOMEM);
+	}
+
+	ch->id = id;
+	ch->time_base = tb;
+	ch->start = start;
+	ch->end = end;
+
+	if (title[0] && av_dict_set(&ch->metadata, "title", title, 0) < 0) {
+		av_free(ch);
+		return AVERROR(ENOMEM);
+	}
+
+	if (av_dynarray_add_nofree(&ctx->chapters, &nb, ch) < 0) {
+		av_dict_free(&ch->metadata);
+		av_free(ch);
+		return AVERROR(ENOMEM);
+	}
+
+	ctx->nb_chapters = nb;
+
+	return 0;
+}
+
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// Chapter of input or output, Start and End are relative to the beginning of file.
+type Chapter struct {
+	Id    int64
+	Start time.Duration
+	End   time.Duration
+	Title string
+}
+
+// Returns chapters of input, or chapters added to output by AddChapter.
+func (this *FmtCtx) Chapters() []Chapter {
+	if this.avCtx == nil {
+		return nil
+	}
+
+	result := make([]Chapter, 0, int(this.avCtx.nb_chapters))
+
+	for i := 0; i < int(this.avCtx.nb_chapters); i++ {
+		ch := C.gmf_get_chapter(this.avCtx, C.int(i))
+		tb := AVRational(ch.time_base)
+
+		md := newDictFromAVDict(ch.metadata)
+		title := md.Get("title")
+		Release(md)
+
+		result = append(result, Chapter{
+			Id:    int64(ch.id),
+			Start: toDuration(int64(ch.start), tb),
+			End:   toDuration(int64(ch.end), tb),
+			Title: title,
+		})
+	}
+
+	return result
+}
+
+// Adds chapter to output, it should be called before WriteHeader.
+func (this *FmtCtx) AddChapter(id int64, start, end time.Duration, title string) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if end < start {
+		return errors.New(fmt.Sprintf("chapter end %v is before its start %v", end, start))
+	}
+
+	ctitle := C.CString(title)
+	defer C.free(unsafe.Pointer(ctitle))
+
+	tb := C.struct_AVRational(AV_TIME_BASE_Q)
+
+	if averr := C.gmf_add_chapter(this.avCtx, C.int64_t(id), tb, C.int64_t(start/time.Microsecond), C.int64_t(end/time.Microsecond), ctitle); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to add chapter: %s", AvError(int(averr))))
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package gmf
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAddChapter(t *testing.T) {
+	outputCtx := assert(NewOutputCtxWithFormatName("", "matroska")).(*FmtCtx)
+	defer Release(outputCtx)
+
+	if err := outputCtx.AddChapter(1, 0, 2*time.Second, "Intro"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := outputCtx.AddChapter(2, 2*time.Second, 5*time.Second, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := outputCtx.AddChapter(3, 5*time.Second, time.Second, "Broken"); err == nil {
+		t.Fatalf("Expected error for chapter ending before its start\n")
+	}
+
+	chapters := outputCtx.Chapters()
+	if len(chapters) != 2 {
+		t.Fatalf("Expected 2 chapters, %d got\n", len(chapters))
+	}
+
+	expected := Chapter{Id: 1, Start: 0, End: 2 * time.Second, Title: "Intro"}
+	if chapters[0] != expected {
+		t.Fatalf("Expected chapter %v, %v got\n", expected, chapters[0])
+	}
+
+	if chapters[1].Start != 2*time.Second || chapters[1].Title != "" {
+		t.Fatalf("Expected untitled chapter at 2s, %v got\n", chapters[1])
+	}
+}
+
+func TestChaptersRoundTrip(t *testing.T) {
+	outputFilename := "examples/tests-chapters.mkv"
+
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
+
+	outputCtx := assert(NewOutputCtx(outputFilename)).(*FmtCtx)
+
+	ost, err := outputCtx.CopyStream(ist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := outputCtx.AddChapter(7, time.Second, 3*time.Second, "Middle"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := outputCtx.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+
+	for p := inputCtx.GetNextPacket(); p != nil; p = inputCtx.GetNextPacket() {
+		if p.StreamIndex() == ist.Index() {
+			p.SetStreamIndex(ost.Index()).RescaleFromTo(ist, ost)
+
+			if err := outputCtx.WritePacket(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		Release(p)
+	}
+
+	outputCtx.CloseOutputAndRelease()
+	defer os.Remove(outputFilename)
+
+	resultCtx := assert(NewInputCtx(outputFilename)).(*FmtCtx)
+	defer resultCtx.CloseInputAndRelease()
+
+	chapters := resultCtx.Chapters()
+	if len(chapters) != 1 {
+		t.Fatalf("Expected 1 chapter, %d got\n", len(chapters))
+	}
+
+	if chapters[0].Start != time.Second || chapters[0].End != 3*time.Second || chapters[0].Title != "Middle" {
+		t.Fatalf("Expected chapter 'Middle' from 1s to 3s, %v got\n", chapters[0])
+	}
+}
@@ -8,6 +8,34 @@ package gmf
 #include "libavcodec/avcodec.h"
 #include "libavutil/pixfmt.h"
 
+static int gmf_codec_pix_fmt(AVCodec *codec, int i) {
+	if (!codec->pix_fmts || codec->pix_fmts[i] == AV_PIX_FMT_NONE)
+		return AV_PIX_FMT_NONE;
+
+	return codec->pix_fmts[i];
+}
+
+static int gmf_codec_sample_fmt(AVCodec *codec, int i) {
+	if (!codec->sample_fmts || codec->sample_fmts[i] == AV_SAMPLE_FMT_NONE)
+		return AV_SAMPLE_FMT_NONE;
+
+	return codec->sample_fmts[i];
+}
+
+static int gmf_codec_sample_rate(AVCodec *codec, int i) {
+	if (!codec->supported_samplerates)
+		return 0;
+
+	return codec->supported_samplerates[i];
+}
+
+static uint64_t gmf_codec_channel_layout(AVCodec *codec, int i) {
+	if (!codec->channel_layouts)
+		return 0;
+
+	return codec->channel_layouts[i];
+}
+
 */
 import "C"
 
@@ -24,6 +52,7 @@ var (
 	AV_PIX_FMT_BGR24        int32 = C.AV_PIX_FMT_BGR24
 	AV_PIX_FMT_GRAY8        int32 = C.AV_PIX_FMT_GRAY8
 	AV_PIX_FMT_RGB24        int32 = C.AV_PIX_FMT_RGB24
+	AV_PIX_FMT_RGBA         int32 = C.AV_PIX_FMT_RGBA
 	AV_PIX_FMT_YUV410P      int32 = C.AV_PIX_FMT_YUV410P
 	AV_PIX_FMT_YUV411P      int32 = C.AV_PIX_FMT_YUV411P
 	AV_PIX_FMT_YUV420P      int32 = C.AV_PIX_FMT_YUV420P
@@ -34,6 +63,22 @@ var (
 	AV_PIX_FMT_NONE         int32 = C.AV_PIX_FMT_NONE
 	FF_PROFILE_MPEG4_SIMPLE int   = C.FF_PROFILE_MPEG4_SIMPLE
 	AV_NOPTS_VALUE          int64 = C.AV_NOPTS_VALUE
+
+	AV_CODEC_CAP_DRAW_HORIZ_BAND     int = C.AV_CODEC_CAP_DRAW_HORIZ_BAND
+	AV_CODEC_CAP_DR1                 int = C.AV_CODEC_CAP_DR1
+	AV_CODEC_CAP_TRUNCATED           int = C.AV_CODEC_CAP_TRUNCATED
+	AV_CODEC_CAP_DELAY               int = C.AV_CODEC_CAP_DELAY
+	AV_CODEC_CAP_SMALL_LAST_FRAME    int = C.AV_CODEC_CAP_SMALL_LAST_FRAME
+	AV_CODEC_CAP_SUBFRAMES           int = C.AV_CODEC_CAP_SUBFRAMES
+	AV_CODEC_CAP_EXPERIMENTAL        int = C.AV_CODEC_CAP_EXPERIMENTAL
+	AV_CODEC_CAP_CHANNEL_CONF        int = C.AV_CODEC_CAP_CHANNEL_CONF
+	AV_CODEC_CAP_FRAME_THREADS       int = C.AV_CODEC_CAP_FRAME_THREADS
+	AV_CODEC_CAP_SLICE_THREADS       int = C.AV_CODEC_CAP_SLICE_THREADS
+	AV_CODEC_CAP_PARAM_CHANGE        int = C.AV_CODEC_CAP_PARAM_CHANGE
+	AV_CODEC_CAP_AUTO_THREADS        int = C.AV_CODEC_CAP_AUTO_THREADS
+	AV_CODEC_CAP_VARIABLE_FRAME_SIZE int = C.AV_CODEC_CAP_VARIABLE_FRAME_SIZE
+	AV_CODEC_CAP_INTRA_ONLY          int = C.AV_CODEC_CAP_INTRA_ONLY
+	AV_CODEC_CAP_LOSSLESS            int = C.AV_CODEC_CAP_LOSSLESS
 )
 
 func init() {
@@ -98,6 +143,17 @@ func FindEncoder(i interface{}) (*Codec, error) {
 	return &Codec{avCodec: avc}, nil
 }
 
+// Finds encoder by implementation name, e.g. "libx264", which is useful
+// when more than one encoder for the same codec id is compiled in.
+func FindEncoderByName(name string) (*Codec, error) {
+	return FindEncoder(name)
+}
+
+// Finds decoder by implementation name, e.g. "h264_cuvid".
+func FindDecoderByName(name string) (*Codec, error) {
+	return FindDecoder(name)
+}
+
 func (this *Codec) Free() {
 	//nothing to do
 }
@@ -119,6 +175,77 @@ func (this *Codec) Type() int {
 	return int(this.avCodec._type)
 }
 
+// Returns pixel formats supported by encoder, nil if they are unknown.
+func (this *Codec) SupportedPixelFormats() []int32 {
+	var result []int32
+
+	for i := 0; ; i++ {
+		f := int32(C.gmf_codec_pix_fmt(this.avCodec, C.int(i)))
+		if f == AV_PIX_FMT_NONE {
+			break
+		}
+		result = append(result, f)
+	}
+
+	return result
+}
+
+// Returns sample formats supported by encoder, nil if they are unknown.
+func (this *Codec) SupportedSampleFormats() []int32 {
+	var result []int32
+
+	for i := 0; ; i++ {
+		f := int32(C.gmf_codec_sample_fmt(this.avCodec, C.int(i)))
+		if f == C.AV_SAMPLE_FMT_NONE {
+			break
+		}
+		result = append(result, f)
+	}
+
+	return result
+}
+
+// Returns sample rates supported by encoder, nil if any rate is accepted or it's unknown.
+func (this *Codec) SupportedSampleRates() []int {
+	var result []int
+
+	for i := 0; ; i++ {
+		r := int(C.gmf_codec_sample_rate(this.avCodec, C.int(i)))
+		if r == 0 {
+			break
+		}
+		result = append(result, r)
+	}
+
+	return result
+}
+
+// Returns channel layouts supported by encoder, nil if they are unknown.
+func (this *Codec) SupportedChannelLayouts() []int64 {
+	var result []int64
+
+	for i := 0; ; i++ {
+		l := int64(C.gmf_codec_channel_layout(this.avCodec, C.int(i)))
+		if l == 0 {
+			break
+		}
+		result = append(result, l)
+	}
+
+	return result
+}
+
+// Returns AV_CODEC_CAP_* flags of codec.
+func (this *Codec) Capabilities() int {
+	return int(this.avCodec.capabilities)
+}
+
+// Reports whether codec has all flags of capability, e.g. AV_CODEC_CAP_DELAY
+// for encoders, which should be flushed.
+func (this *Codec) HasCapability(flag int) bool {
+	return this.Capabilities()&flag == flag
+}
+
 func (this *Codec) IsExperimental() bool {
 	return bool((this.avCodec.capabilities & C.CODEC_CAP_EXPERIMENTAL) != 0)
 }
@@ -4,6 +4,7 @@ package gmf
 
 #cgo pkg-config: libavcodec libavutil
 
+#include <stdlib.h>
 #include <string.h>
 
 #include "libavcodec/avcodec.h"
@@ -15,6 +16,9 @@ package gmf
 static int check_sample_fmt(AVCodec *codec, enum AVSampleFormat sample_fmt) {
     const enum AVSampleFormat *p = codec->sample_fmts;
 
+    if (!p)
+        return 1;
+
     while (*p != AV_SAMPLE_FMT_NONE) {
         if (*p == sample_fmt)
             return 1;
@@ -23,6 +27,34 @@ static int check_sample_fmt(AVCodec *codec, enum AVSampleFormat sample_fmt) {
     return 0;
 }
 
+static int check_sample_rate(AVCodec *codec, int sample_rate) {
+    const int *p = codec->supported_samplerates;
+
+    if (!p)
+        return 1;
+
+    while (*p) {
+        if (*p == sample_rate)
+            return 1;
+        p++;
+    }
+    return 0;
+}
+
+static int check_channel_layout(AVCodec *codec, uint64_t channel_layout) {
+    const uint64_t *p = codec->channel_layouts;
+
+    if (!p)
+        return 1;
+
+    while (*p) {
+        if (*p == channel_layout)
+            return 1;
+        p++;
+    }
+    return 0;
+}
+
 static int select_sample_rate(AVCodec *codec) {
     const int *p;
     int best_samplerate = 0;
@@ -38,7 +70,7 @@ static int select_sample_rate(AVCodec *codec) {
     return best_samplerate;
 }
 
-static int select_channel_layout(AVCodec *codec) {
+static uint64_t select_channel_layout(AVCodec *codec) {
     const uint64_t *p;
     uint64_t best_ch_layout = 0;
     int best_nb_channels    = 0;
@@ -65,6 +97,9 @@ import "C"
 import (
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"unsafe"
 	//	"log"
 )
@@ -87,11 +122,23 @@ var (
 	AV_CODEC_ID_GIF        int = C.AV_CODEC_ID_GIF
 
 	CODEC_FLAG_GLOBAL_HEADER int   = C.CODEC_FLAG_GLOBAL_HEADER
+	AV_CODEC_FLAG_PASS1      int   = C.AV_CODEC_FLAG_PASS1
+	AV_CODEC_FLAG_PASS2      int   = C.AV_CODEC_FLAG_PASS2
+	AV_CODEC_FLAG_QSCALE     int   = C.AV_CODEC_FLAG_QSCALE
+	FF_QP2LAMBDA             int   = C.FF_QP2LAMBDA
 	FF_MB_DECISION_SIMPLE    int   = C.FF_MB_DECISION_SIMPLE
 	FF_MB_DECISION_BITS      int   = C.FF_MB_DECISION_BITS
 	FF_MB_DECISION_RD        int   = C.FF_MB_DECISION_RD
 	AV_SAMPLE_FMT_S16        int32 = C.AV_SAMPLE_FMT_S16
 	AV_SAMPLE_FMT_S16P       int32 = C.AV_SAMPLE_FMT_S16P
+	AV_SAMPLE_FMT_U8         int32 = C.AV_SAMPLE_FMT_U8
+	AV_SAMPLE_FMT_U8P        int32 = C.AV_SAMPLE_FMT_U8P
+	AV_SAMPLE_FMT_S32        int32 = C.AV_SAMPLE_FMT_S32
+	AV_SAMPLE_FMT_S32P       int32 = C.AV_SAMPLE_FMT_S32P
+	AV_SAMPLE_FMT_FLT        int32 = C.AV_SAMPLE_FMT_FLT
+	AV_SAMPLE_FMT_FLTP       int32 = C.AV_SAMPLE_FMT_FLTP
+	AV_SAMPLE_FMT_DBL        int32 = C.AV_SAMPLE_FMT_DBL
+	AV_SAMPLE_FMT_DBLP       int32 = C.AV_SAMPLE_FMT_DBLP
 )
 
 type SampleFmt int
@@ -99,6 +146,7 @@ type SampleFmt int
 type CodecCtx struct {
 	codec      *Codec
 	avCodecCtx *C.struct_AVCodecContext
+	statsOut   *os.File
 	CgoMemoryManage
 }
 
@@ -122,9 +170,44 @@ func NewCodecCtx(codec *Codec, options ...[]*Option) *CodecCtx {
 		}
 	}
 
+	setAutoRelease(result)
+
 	return result
 }
 
+// Returns copy of context with the same parameters, e.g. to reuse configured encoder
+// as a template. Private options of the codec (e.g. x264 "preset") are carried over too.
+// Copy is returned not opened, regardless of the state of the original, so Open should
+// be called on it.
+func (this *CodecCtx) Copy() (*CodecCtx, error) {
+	if this.avCodecCtx == nil {
+		return nil, ErrNilContext
+	}
+
+	result := NewCodecCtx(this.codec)
+	if result == nil {
+		return nil, errors.New("Unable to allocate codec context")
+	}
+
+	if averr := C.avcodec_copy_context(result.avCodecCtx, this.avCodecCtx); averr < 0 {
+		Release(result)
+		return nil, errors.New(fmt.Sprintf("Unable to copy codec context: %s", AvError(int(averr))))
+	}
+
+	// avcodec_copy_context copies these pointers as is, but both contexts free them on Close
+	result.avCodecCtx.stats_in = nil
+	if this.avCodecCtx.stats_in != nil {
+		result.avCodecCtx.stats_in = C.av_strdup(this.avCodecCtx.stats_in)
+	}
+
+	result.avCodecCtx.hw_device_ctx = nil
+	if this.avCodecCtx.hw_device_ctx != nil {
+		result.avCodecCtx.hw_device_ctx = C.av_buffer_ref(this.avCodecCtx.hw_device_ctx)
+	}
+
+	return result, nil
+}
+
 func (this *CodecCtx) CopyExtra(ist *Stream) *CodecCtx {
 	codec := this.avCodecCtx
 	icodec := ist.CodecCtx().avCodecCtx
@@ -175,6 +258,10 @@ func (this *CodecCtx) CopyBasic(ist *Stream) *CodecCtx {
 }
 
 func (this *CodecCtx) Open(dict *Dict) error {
+	if this.avCodecCtx == nil {
+		return ErrNilContext
+	}
+
 	if this.IsOpen() {
 		return nil
 	}
@@ -193,9 +280,54 @@ func (this *CodecCtx) Open(dict *Dict) error {
 
 func (this *CodecCtx) Close() {
 	if nil != this.avCodecCtx {
+		if this.avCodecCtx.hw_device_ctx != nil {
+			C.av_buffer_unref(&this.avCodecCtx.hw_device_ctx)
+		}
+
 		C.avcodec_close(this.avCodecCtx)
+		C.av_freep(unsafe.Pointer(&this.avCodecCtx.stats_in))
 		this.avCodecCtx = nil
 	}
+
+	if this.statsOut != nil {
+		this.statsOut.Close()
+		this.statsOut = nil
+	}
+}
+
+// Returns copy of codec extradata, e.g. SPS/PPS for H.264 or AudioSpecificConfig for AAC.
+// For encoders it's filled by Open, if CODEC_FLAG_GLOBAL_HEADER is set.
+func (this *CodecCtx) ExtraData() []byte {
+	if this.avCodecCtx == nil || this.avCodecCtx.extradata == nil || this.avCodecCtx.extradata_size <= 0 {
+		return nil
+	}
+
+	return C.GoBytes(unsafe.Pointer(this.avCodecCtx.extradata), this.avCodecCtx.extradata_size)
+}
+
+// Replaces codec extradata with a copy of data, empty data removes it. Should be called before Open.
+// Existing extradata is kept, if allocation fails.
+func (this *CodecCtx) SetExtraData(data []byte) error {
+	if this.avCodecCtx == nil {
+		return ErrNilContext
+	}
+
+	var extradata *C.uint8_t
+
+	if len(data) > 0 {
+		extradata = (*C.uint8_t)(C.av_mallocz(C.size_t(len(data) + C.FF_INPUT_BUFFER_PADDING_SIZE)))
+		if extradata == nil {
+			return errors.New(fmt.Sprintf("Unable to allocate %d bytes of extradata", len(data)))
+		}
+
+		C.memcpy(unsafe.Pointer(extradata), unsafe.Pointer(&data[0]), C.size_t(len(data)))
+	}
+
+	C.av_freep(unsafe.Pointer(&this.avCodecCtx.extradata))
+	this.avCodecCtx.extradata = extradata
+	this.avCodecCtx.extradata_size = C.int(len(data))
+
+	return nil
 }
 
 func (this *CodecCtx) Free() {
@@ -207,10 +339,66 @@ func (this *CodecCtx) CloseAndRelease() {
 	C.av_freep(unsafe.Pointer(&this.avCodecCtx))
 }
 
-// @todo
-func (this *CodecCtx) SetOpt() {
-	// mock
-	C.av_opt_set_int(unsafe.Pointer(this.avCodecCtx), C.CString("refcounted_frames"), 1, 0)
+// Sets codec private option, e.g. "preset" or "crf" for libx264.
+// Should be called before Open.
+func (this *CodecCtx) SetOpt(name, value string) error {
+	if this.avCodecCtx == nil {
+		return ErrNilContext
+	}
+
+	if this.avCodecCtx.priv_data == nil {
+		return errors.New(fmt.Sprintf("Codec '%s' has no private options", this.codec.Name()))
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	cvalue := C.CString(value)
+	defer C.free(unsafe.Pointer(cvalue))
+
+	return this.checkOpt(name, value, C.av_opt_set(this.avCodecCtx.priv_data, cname, cvalue, 0))
+}
+
+func (this *CodecCtx) SetOptInt(name string, value int64) error {
+	if this.avCodecCtx == nil {
+		return ErrNilContext
+	}
+
+	if this.avCodecCtx.priv_data == nil {
+		return errors.New(fmt.Sprintf("Codec '%s' has no private options", this.codec.Name()))
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	return this.checkOpt(name, value, C.av_opt_set_int(this.avCodecCtx.priv_data, cname, C.int64_t(value), 0))
+}
+
+func (this *CodecCtx) SetOptDouble(name string, value float64) error {
+	if this.avCodecCtx == nil {
+		return ErrNilContext
+	}
+
+	if this.avCodecCtx.priv_data == nil {
+		return errors.New(fmt.Sprintf("Codec '%s' has no private options", this.codec.Name()))
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	return this.checkOpt(name, value, C.av_opt_set_double(this.avCodecCtx.priv_data, cname, C.double(value), 0))
+}
+
+func (this *CodecCtx) checkOpt(name string, value interface{}, averr C.int) error {
+	if averr == C.AVERROR_OPTION_NOT_FOUND {
+		return errors.New(fmt.Sprintf("Option '%s' is unknown to codec '%s'", name, this.codec.Name()))
+	}
+
+	if averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to set option '%s' to '%v': %s", name, value, AvError(int(averr))))
+	}
+
+	return nil
 }
 
 func (this *CodecCtx) Codec() *Codec {
@@ -233,7 +421,13 @@ func (this *CodecCtx) Height() int {
 	return int(this.avCodecCtx.height)
 }
 
+// Returns pixel format, for opened decoder it's the format of decoded frames.
+// AV_PIX_FMT_NONE is returned for freed context.
 func (this *CodecCtx) PixFmt() int32 {
+	if this.avCodecCtx == nil {
+		return AV_PIX_FMT_NONE
+	}
+
 	return int32(this.avCodecCtx.pix_fmt)
 }
 
@@ -241,8 +435,14 @@ func (this *CodecCtx) FrameSize() int {
 	return int(this.avCodecCtx.frame_size)
 }
 
+// Returns sample format, for opened decoder it's the format of decoded frames.
+// AV_SAMPLE_FMT_NONE is returned for freed context.
 func (this *CodecCtx) SampleFmt() int32 {
-	return this.avCodecCtx.sample_fmt
+	if this.avCodecCtx == nil {
+		return int32(C.AV_SAMPLE_FMT_NONE)
+	}
+
+	return int32(this.avCodecCtx.sample_fmt)
 }
 
 func (this *CodecCtx) SampleRate() int {
@@ -254,7 +454,7 @@ func (this *CodecCtx) Profile() int {
 }
 
 func (this *CodecCtx) IsOpen() bool {
-	return (int(C.avcodec_is_open(this.avCodecCtx)) > 0)
+	return this.avCodecCtx != nil && (int(C.avcodec_is_open(this.avCodecCtx)) > 0)
 }
 
 func (this *CodecCtx) SetProfile(profile int) *CodecCtx {
@@ -266,23 +466,28 @@ func (this *CodecCtx) TimeBase() AVRational {
 	return AVRational(this.avCodecCtx.time_base)
 }
 
-func (this *CodecCtx) ChannelLayout() int {
-	return int(this.avCodecCtx.channel_layout)
+func (this *CodecCtx) ChannelLayout() int64 {
+	return int64(this.avCodecCtx.channel_layout)
 }
-func (this *CodecCtx) SetChannelLayout(channelLayout int) {
+
+// Sets channel layout and number of channels by it. Use CheckAudioParams to find out,
+// whether encoder supports it.
+func (this *CodecCtx) SetChannelLayout(channelLayout int64) *CodecCtx {
 	this.avCodecCtx.channel_layout = C.uint64_t(channelLayout)
+	this.avCodecCtx.channels = C.av_get_channel_layout_nb_channels(C.uint64_t(channelLayout))
+	return this
 }
 
-func (this *CodecCtx) BitRate() int {
-	return int(this.avCodecCtx.bit_rate)
+func (this *CodecCtx) BitRate() int64 {
+	return int64(this.avCodecCtx.bit_rate)
 }
 
 func (this *CodecCtx) Channels() int {
 	return int(this.avCodecCtx.channels)
 }
 
-func (this *CodecCtx) SetBitRate(val int) *CodecCtx {
-	this.avCodecCtx.bit_rate = C.int(val)
+func (this *CodecCtx) SetBitRate(val int64) *CodecCtx {
+	this.avCodecCtx.bit_rate = C.int64_t(val)
 	return this
 }
 
@@ -318,6 +523,22 @@ func (this *CodecCtx) SetMaxBFrames(val int) *CodecCtx {
 	return this
 }
 
+func (this *CodecCtx) SetQMin(val int) *CodecCtx {
+	this.avCodecCtx.qmin = C.int(val)
+	return this
+}
+
+func (this *CodecCtx) SetQMax(val int) *CodecCtx {
+	this.avCodecCtx.qmax = C.int(val)
+	return this
+}
+
+// Sets decoder bitstream buffer size in bits, used by rate control.
+func (this *CodecCtx) SetRcBufferSize(val int) *CodecCtx {
+	this.avCodecCtx.rc_buffer_size = C.int(val)
+	return this
+}
+
 func (this *CodecCtx) SetPixFmt(val int32) *CodecCtx {
 	this.avCodecCtx.pix_fmt = val
 	return this
@@ -328,13 +549,19 @@ func (this *CodecCtx) SetFlag(flag int) *CodecCtx {
 	return this
 }
 
+// Combination of AV_CODEC_FLAG_* values.
+func (this *CodecCtx) Flags() int {
+	return int(this.avCodecCtx.flags)
+}
+
 func (this *CodecCtx) SetMbDecision(val int) *CodecCtx {
 	this.avCodecCtx.mb_decision = C.int(val)
 	return this
 }
 
+// Panics if encoder doesn't support val.
 func (this *CodecCtx) SetSampleFmt(val int32) *CodecCtx {
-	if int(C.check_sample_fmt(this.codec.avCodec, val)) == 0 {
+	if this.codec != nil && int(C.check_sample_fmt(this.codec.avCodec, val)) == 0 {
 		panic(fmt.Sprintf("encoder doesn't support sample format %s", GetSampleFmtName(val)))
 	}
 
@@ -342,11 +569,38 @@ func (this *CodecCtx) SetSampleFmt(val int32) *CodecCtx {
 	return this
 }
 
+// Use CheckAudioParams to find out, whether encoder supports val.
 func (this *CodecCtx) SetSampleRate(val int) *CodecCtx {
 	this.avCodecCtx.sample_rate = C.int(val)
 	return this
 }
 
+// Returns error if encoder doesn't support sample format, sample rate or channel layout,
+// which are set. Otherwise Open would fail with less descriptive error.
+func (this *CodecCtx) CheckAudioParams() error {
+	if this.avCodecCtx == nil {
+		return ErrNilContext
+	}
+
+	if this.codec == nil {
+		return nil
+	}
+
+	if int(C.check_sample_fmt(this.codec.avCodec, this.avCodecCtx.sample_fmt)) == 0 {
+		return errors.New(fmt.Sprintf("encoder '%s' doesn't support sample format %s", this.codec.Name(), GetSampleFmtName(this.SampleFmt())))
+	}
+
+	if int(C.check_sample_rate(this.codec.avCodec, this.avCodecCtx.sample_rate)) == 0 {
+		return errors.New(fmt.Sprintf("encoder '%s' doesn't support sample rate %d", this.codec.Name(), this.SampleRate()))
+	}
+
+	if this.avCodecCtx.channel_layout != 0 && int(C.check_channel_layout(this.codec.avCodec, this.avCodecCtx.channel_layout)) == 0 {
+		return errors.New(fmt.Sprintf("encoder '%s' doesn't support channel layout %d", this.codec.Name(), this.ChannelLayout()))
+	}
+
+	return nil
+}
+
 var (
 	FF_COMPLIANCE_VERY_STRICT  int = C.FF_COMPLIANCE_VERY_STRICT
 	FF_COMPLIANCE_STRICT       int = C.FF_COMPLIANCE_STRICT
@@ -360,6 +614,32 @@ func (this *CodecCtx) SetStrictCompliance(val int) *CodecCtx {
 	return this
 }
 
+var (
+	FF_THREAD_FRAME int = C.FF_THREAD_FRAME
+	FF_THREAD_SLICE int = C.FF_THREAD_SLICE
+)
+
+// Sets number of threads, 0 lets the codec choose. Should be set before Open.
+func (this *CodecCtx) SetThreadCount(n int) *CodecCtx {
+	this.avCodecCtx.thread_count = C.int(n)
+	return this
+}
+
+func (this *CodecCtx) ThreadCount() int {
+	return int(this.avCodecCtx.thread_count)
+}
+
+// Sets allowed threading methods, FF_THREAD_FRAME and/or FF_THREAD_SLICE. Should be set before Open.
+func (this *CodecCtx) SetThreadType(t int) *CodecCtx {
+	this.avCodecCtx.thread_type = C.int(t)
+	return this
+}
+
+// Returns threading method in use, it's known after Open.
+func (this *CodecCtx) ActiveThreadType() int {
+	return int(this.avCodecCtx.active_thread_type)
+}
+
 func (this *CodecCtx) SetHasBframes(val int) *CodecCtx {
 	this.avCodecCtx.has_b_frames = C.int(val)
 	return this
@@ -374,8 +654,196 @@ func (this *CodecCtx) SelectSampleRate() int {
 	return int(C.select_sample_rate(this.codec.avCodec))
 }
 
-func (this *CodecCtx) SelectChannelLayout() int {
-	return int(C.select_channel_layout(this.codec.avCodec))
+func (this *CodecCtx) SelectChannelLayout() int64 {
+	return int64(C.select_channel_layout(this.codec.avCodec))
+}
+
+// Sends packet to decoder and returns all frames it's ready to give back, it can be zero or more.
+// Nil packet flushes decoder, remaining frames are returned with io.EOF.
+func (this *CodecCtx) Decode(pkt *Packet) ([]*Frame, error) {
+	if this.avCodecCtx == nil {
+		return nil, ErrNilContext
+	}
+
+	var avPacket *C.struct_AVPacket
+
+	if pkt != nil {
+		avPacket = &pkt.avPacket
+	}
+
+	if averr := int(C.avcodec_send_packet(this.avCodecCtx, avPacket)); averr < 0 {
+		if averr == AVERROR_EOF {
+			return nil, io.EOF
+		}
+
+		if averr != AVERROR_EAGAIN {
+			return nil, errors.New(fmt.Sprintf("Unable to send packet to decoder: %s", AvError(averr)))
+		}
+	}
+
+	frames := make([]*Frame, 0)
+
+	for {
+		frame := NewFrame()
+		frame.mediaType = this.Type()
+
+		averr := int(C.avcodec_receive_frame(this.avCodecCtx, frame.avFrame))
+		if averr < 0 {
+			Release(frame)
+
+			if averr == AVERROR_EAGAIN {
+				return frames, nil
+			}
+
+			if averr == AVERROR_EOF {
+				return frames, io.EOF
+			}
+
+			return frames, errors.New(fmt.Sprintf("Unable to receive frame from decoder: %s", AvError(averr)))
+		}
+
+		frames = append(frames, frame)
+	}
+}
+
+// Sends frame to encoder and returns all packets it's ready to give back.
+// Nil frame flushes encoder, remaining packets are returned with io.EOF.
+// Packets timestamps are in codec time base and stream index is not set,
+// rescaling them to the output stream is up to caller.
+func (this *CodecCtx) Encode(f *Frame) ([]*Packet, error) {
+	if this.avCodecCtx == nil {
+		return nil, ErrNilContext
+	}
+
+	var avFrame *C.struct_AVFrame
+
+	if f != nil {
+		avFrame = f.avFrame
+	}
+
+	if averr := int(C.avcodec_send_frame(this.avCodecCtx, avFrame)); averr < 0 {
+		if averr == AVERROR_EOF {
+			return nil, io.EOF
+		}
+
+		if averr != AVERROR_EAGAIN {
+			return nil, errors.New(fmt.Sprintf("Unable to send frame to encoder: %s", AvError(averr)))
+		}
+	}
+
+	packets := make([]*Packet, 0)
+
+	for {
+		p := NewPacket()
+
+		averr := int(C.avcodec_receive_packet(this.avCodecCtx, &p.avPacket))
+		if averr < 0 {
+			Release(p)
+
+			if averr == AVERROR_EAGAIN {
+				return packets, nil
+			}
+
+			if averr == AVERROR_EOF {
+				return packets, io.EOF
+			}
+
+			return packets, errors.New(fmt.Sprintf("Unable to receive packet from encoder: %s", AvError(averr)))
+		}
+
+		if this.statsOut != nil && this.avCodecCtx.stats_out != nil {
+			if _, err := this.statsOut.WriteString(C.GoString(this.avCodecCtx.stats_out)); err != nil {
+				Release(p)
+				return packets, errors.New(fmt.Sprintf("Unable to write encoder stats: %s", err))
+			}
+		}
+
+		packets = append(packets, p)
+	}
+}
+
+// Drains encoder and returns all remaining packets, e.g. delayed B-frames.
+// The same as Encode(nil), but end of stream isn't reported as error.
+func (this *CodecCtx) Flush() ([]*Packet, error) {
+	packets, err := this.Encode(nil)
+	if err == io.EOF {
+		err = nil
+	}
+
+	return packets, err
+}
+
+// Drains decoder and returns all remaining frames.
+// The same as Decode(nil), but end of stream isn't reported as error.
+func (this *CodecCtx) FlushDecoder() ([]*Frame, error) {
+	frames, err := this.Decode(nil)
+	if err == io.EOF {
+		err = nil
+	}
+
+	return frames, err
+}
+
+// Enables two-pass encoding, should be called before Open. The whole input should be
+// encoded twice with separate contexts: first with pass 1, which writes statistics into
+// statsFile while encoding (packets can be dropped), then with pass 2, which reads them.
+// Statistics are collected by Encode. Libx264 reads and writes its own stats file, its name
+// is passed by the "stats" private option. Calling it again replaces previous settings.
+func (this *CodecCtx) SetPass(pass int, statsFile string) error {
+	if this.avCodecCtx == nil {
+		return ErrNilContext
+	}
+
+	if pass != 1 && pass != 2 {
+		return errors.New(fmt.Sprintf("Unexpected pass %d, should be 1 or 2", pass))
+	}
+
+	if this.statsOut != nil {
+		this.statsOut.Close()
+		this.statsOut = nil
+	}
+
+	this.avCodecCtx.flags &^= C.int(AV_CODEC_FLAG_PASS1 | AV_CODEC_FLAG_PASS2)
+
+	if this.codec != nil && this.codec.Name() == "libx264" {
+		if err := this.SetOpt("stats", statsFile); err != nil {
+			return err
+		}
+
+		if pass == 1 {
+			this.avCodecCtx.flags |= C.int(AV_CODEC_FLAG_PASS1)
+		} else {
+			this.avCodecCtx.flags |= C.int(AV_CODEC_FLAG_PASS2)
+		}
+
+		return nil
+	}
+
+	switch pass {
+	case 1:
+		f, err := os.Create(statsFile)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to create stats file: %s", err))
+		}
+
+		this.statsOut = f
+		this.avCodecCtx.flags |= C.int(AV_CODEC_FLAG_PASS1)
+
+	case 2:
+		stats, err := ioutil.ReadFile(statsFile)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to read stats file: %s", err))
+		}
+
+		cstats := C.CString(string(stats))
+		defer C.free(unsafe.Pointer(cstats))
+
+		C.av_freep(unsafe.Pointer(&this.avCodecCtx.stats_in))
+		this.avCodecCtx.stats_in = C.av_strdup(cstats)
+		this.avCodecCtx.flags |= C.int(AV_CODEC_FLAG_PASS2)
+	}
+
+	return nil
 }
 
 func (this *CodecCtx) FlushBuffers() {
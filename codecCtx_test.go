@@ -1,7 +1,10 @@
 package gmf
 
 import (
+	"io"
+	"io/ioutil"
 	"log"
+	"os"
 	"testing"
 )
 
@@ -10,7 +13,7 @@ var CodecCtxTestData = struct {
 	height   int
 	timebase AVR
 	pixfmt   int32
-	bitrate  int
+	bitrate  int64
 }{
 	100,
 	200,
@@ -50,6 +53,10 @@ func TestCodecCtx(t *testing.T) {
 		t.Fatalf("Expected pixfmt = %v, %v got.\n", td.pixfmt, cc.PixFmt())
 	}
 
+	if err := cc.SetOpt("not_existing_option", "1"); err == nil {
+		t.Fatal("Expected error for unknown option")
+	}
+
 	if err := cc.Open(nil); err != nil {
 		t.Fatal(err)
 	}
@@ -58,3 +65,373 @@ func TestCodecCtx(t *testing.T) {
 
 	Release(cc)
 }
+
+func TestCodecCtxEncode(t *testing.T) {
+	codec, err := FindEncoder("mpeg4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cc := NewCodecCtx(codec)
+	defer Release(cc)
+
+	cc.SetDimension(320, 200).SetTimeBase(AVR{1, 25}).SetPixFmt(AV_PIX_FMT_YUV420P).SetBitRate(400000).SetMaxBFrames(2).
+		SetGopSize(12).SetQMin(2).SetQMax(31).SetRcBufferSize(800000)
+
+	if err := cc.Open(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	total, i := 0, int64(0)
+
+	for frame := range GenSyntVideoNewFrame(320, 200, AV_PIX_FMT_YUV420P) {
+		frame.SetPts(i)
+		i++
+
+		packets, err := cc.Encode(frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, p := range packets {
+			Release(p)
+		}
+
+		total += len(packets)
+		Release(frame)
+	}
+
+	packets, err := cc.Encode(nil)
+	if err != io.EOF {
+		t.Fatalf("Expected io.EOF, '%v' got\n", err)
+	}
+
+	for _, p := range packets {
+		Release(p)
+	}
+
+	total += len(packets)
+
+	if total != int(i) {
+		t.Fatalf("Expected %d packets, %d got\n", i, total)
+	}
+
+	log.Println(total, "packets encoded")
+}
+
+func TestCodecCtxFlush(t *testing.T) {
+	codec, err := FindEncoder("mpeg4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cc := NewCodecCtx(codec)
+	defer Release(cc)
+
+	cc.SetDimension(320, 200).SetTimeBase(AVR{1, 25}).SetPixFmt(AV_PIX_FMT_YUV420P).SetMaxBFrames(2)
+
+	if err := cc.Open(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	total, i := 0, int64(0)
+
+	for frame := range GenSyntVideoNewFrame(320, 200, AV_PIX_FMT_YUV420P) {
+		frame.SetPts(i)
+		i++
+
+		packets, err := cc.Encode(frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, p := range packets {
+			Release(p)
+		}
+
+		total += len(packets)
+		Release(frame)
+	}
+
+	packets, err := cc.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range packets {
+		Release(p)
+	}
+
+	if total+len(packets) != int(i) || len(packets) == 0 {
+		t.Fatalf("Expected %d packets with delayed ones, %d got\n", i, total+len(packets))
+	}
+
+	if packets, err := cc.Flush(); err != nil || len(packets) != 0 {
+		t.Fatalf("Expected no packets after flush, %d, '%v' got\n", len(packets), err)
+	}
+}
+
+func TestCodecCtxThreads(t *testing.T) {
+	codec, err := FindEncoder("mpeg4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cc := NewCodecCtx(codec)
+	defer Release(cc)
+
+	cc.SetDimension(320, 200).SetTimeBase(AVR{1, 25}).SetPixFmt(AV_PIX_FMT_YUV420P).SetThreadCount(2).SetThreadType(FF_THREAD_SLICE)
+
+	if cc.ThreadCount() != 2 {
+		t.Fatalf("Expected thread count = 2, %d got\n", cc.ThreadCount())
+	}
+
+	if err := cc.Open(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	log.Println("CodecCtx is opened with thread type", cc.ActiveThreadType())
+}
+
+func TestCodecCtxAudio(t *testing.T) {
+	codec, err := FindEncoder("mp2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cc := NewCodecCtx(codec)
+	defer Release(cc)
+
+	layout := cc.SelectChannelLayout()
+
+	cc.SetSampleFmt(AV_SAMPLE_FMT_S16).SetSampleRate(44100).SetChannelLayout(layout).SetBitRate(128000)
+
+	if cc.Channels() != 2 {
+		t.Fatalf("Expected 2 channels, %d got\n", cc.Channels())
+	}
+
+	if cc.BitRate() != 128000 {
+		t.Fatalf("Expected bit rate 128000, %d got\n", cc.BitRate())
+	}
+
+	if err := cc.CheckAudioParams(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cc.Open(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	unsupported := NewCodecCtx(codec)
+	defer Release(unsupported)
+
+	unsupported.SetSampleFmt(AV_SAMPLE_FMT_S16).SetSampleRate(12345).SetChannelLayout(layout)
+
+	if err := unsupported.CheckAudioParams(); err == nil {
+		t.Fatal("Expected error for unsupported sample rate")
+	}
+}
+
+func TestCodecCtxDecoderFormat(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inputCtx.CloseInputAndRelease()
+
+	_, cc, err := inputCtx.GetBestStreamWithDecoder(AVMEDIA_TYPE_VIDEO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(cc)
+
+	// frame is decoded from separate context, so cc isn't touched by decodeFirstFrame
+	frameCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer frameCtx.CloseInputAndRelease()
+
+	frame, err := decodeFirstFrame(frameCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(frame)
+
+	if cc.PixFmt() != frame.Format() {
+		t.Fatalf("Expected pixel format %d, %d got\n", frame.Format(), cc.PixFmt())
+	}
+
+	log.Printf("Decoder pixel format: %d\n", cc.PixFmt())
+}
+
+func TestCodecCtxExtraData(t *testing.T) {
+	codec, err := FindEncoder("mpeg4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cc := NewCodecCtx(codec)
+	defer Release(cc)
+
+	if cc.ExtraData() != nil {
+		t.Fatal("Expected no extradata")
+	}
+
+	cc.SetDimension(320, 200).SetTimeBase(AVR{1, 25}).SetPixFmt(AV_PIX_FMT_YUV420P).SetFlag(CODEC_FLAG_GLOBAL_HEADER)
+
+	if err := cc.Open(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cc.ExtraData()) == 0 {
+		t.Fatal("Expected extradata with global header")
+	}
+
+	if err := cc.SetExtraData([]byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	if data := cc.ExtraData(); len(data) != 3 || data[2] != 3 {
+		t.Fatalf("Expected [1 2 3], %v got\n", data)
+	}
+
+	cc.Close()
+
+	if cc.ExtraData() != nil {
+		t.Fatal("Expected no extradata for closed context")
+	}
+
+	if err := cc.SetExtraData([]byte{1}); err != ErrNilContext {
+		t.Fatalf("Expected ErrNilContext, '%v' got\n", err)
+	}
+}
+
+func TestCodecCtxTwoPass(t *testing.T) {
+	statsFile := "tests-2pass.log"
+	defer os.Remove(statsFile)
+
+	for pass := 1; pass <= 2; pass++ {
+		codec := assert(FindEncoder("mpeg4")).(*Codec)
+
+		cc := NewCodecCtx(codec)
+		cc.SetDimension(320, 200).SetTimeBase(AVR{1, 25}).SetPixFmt(AV_PIX_FMT_YUV420P).SetBitRate(400000)
+
+		if err := cc.SetPass(pass, statsFile); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := cc.Open(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		i := int64(0)
+		for frame := range GenSyntVideoNewFrame(320, 200, AV_PIX_FMT_YUV420P) {
+			packets, err := cc.Encode(frame.SetPts(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, p := range packets {
+				Release(p)
+			}
+
+			i++
+			Release(frame)
+		}
+
+		packets, _ := cc.Encode(nil)
+		for _, p := range packets {
+			Release(p)
+		}
+
+		Release(cc)
+
+		if fi, err := os.Stat(statsFile); err != nil || fi.Size() == 0 {
+			t.Fatalf("Expected non empty stats file after pass %d, %v\n", pass, err)
+		}
+	}
+
+	log.Println("Two-pass encoding is done")
+}
+
+func TestCodecCtxSetPassTwice(t *testing.T) {
+	statsFile := "tests-2pass-twice.log"
+	defer os.Remove(statsFile)
+
+	cc := NewCodecCtx(assert(FindEncoder("mpeg4")).(*Codec))
+	defer Release(cc)
+
+	if err := cc.SetPass(1, statsFile); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := cc.statsOut
+
+	if err := cc.SetPass(1, statsFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := prev.WriteString("stats"); err == nil {
+		t.Fatal("Expected previous stats file to be closed")
+	}
+}
+
+func TestCodecCtxCopy(t *testing.T) {
+	codec := assert(FindEncoder("mpeg4")).(*Codec)
+
+	cc := NewCodecCtx(codec)
+	defer Release(cc)
+
+	cc.SetDimension(320, 200).SetTimeBase(AVR{1, 25}).SetPixFmt(AV_PIX_FMT_YUV420P).SetBitRate(400000).SetGopSize(12)
+
+	if err := cc.SetOpt("data_partitioning", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cc.Open(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := cc.Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(cp)
+
+	if cp.IsOpen() {
+		t.Fatal("Expected copy to be not opened")
+	}
+
+	if cp.Width() != 320 || cp.Height() != 200 || cp.BitRate() != 400000 {
+		t.Fatalf("Unexpected copy parameters %dx%d, bit rate %d\n", cp.Width(), cp.Height(), cp.BitRate())
+	}
+
+	if err := cp.Open(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCodecCtxCopyStatsIn(t *testing.T) {
+	statsFile := "tests-copy-2pass.log"
+	defer os.Remove(statsFile)
+
+	if err := ioutil.WriteFile(statsFile, []byte("stats"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	codec := assert(FindEncoder("mpeg4")).(*Codec)
+
+	cc := NewCodecCtx(codec)
+	defer Release(cc)
+
+	if err := cc.SetPass(2, statsFile); err != nil {
+		t.Fatal(err)
+	}
+
+	cp := assert(cc.Copy()).(*CodecCtx)
+
+	if cp.avCodecCtx.stats_in == nil || cp.avCodecCtx.stats_in == cc.avCodecCtx.stats_in {
+		t.Fatal("Expected copy to own its stats buffer")
+	}
+
+	// closing both contexts shouldn't free the same buffer twice
+	Release(cp)
+}
@@ -15,6 +15,7 @@ import (
 
 type CodecDescriptor struct {
 	avDesc    *C.struct_AVCodecDescriptor
+	avCodec   *C.struct_AVCodec
 	IsEncoder bool
 }
 
@@ -40,7 +41,7 @@ func InitDesc() {
 			log.Printf("Unable to get descriptor for codec id: %d\n", int(c.id))
 		}
 
-		result := &CodecDescriptor{avDesc: desc, IsEncoder: false}
+		result := &CodecDescriptor{avDesc: desc, avCodec: c, IsEncoder: false}
 
 		if C.av_codec_is_encoder(c) > 0 {
 			result.IsEncoder = true
@@ -70,3 +71,8 @@ func (this *CodecDescriptor) LongName() string {
 func (this *CodecDescriptor) Props() int {
 	return int(this.avDesc.props)
 }
+
+// Returns AV_CODEC_CAP_* flags of codec implementation.
+func (this *CodecDescriptor) Capabilities() int {
+	return int(this.avCodec.capabilities)
+}
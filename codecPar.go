@@ -0,0 +1,98 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavcodec
+
+#include "libavcodec/avcodec.h"
+
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Codec parameters of a stream. Unlike CodecCtx it describes encoded stream only
+// and doesn't hold any codec state.
+type CodecParameters struct {
+	avCodecPar *C.struct_AVCodecParameters
+	owned      bool
+	CgoMemoryManage
+}
+
+func NewCodecParameters() *CodecParameters {
+	par := C.avcodec_parameters_alloc()
+	if par == nil {
+		return nil
+	}
+
+	return &CodecParameters{avCodecPar: par, owned: true}
+}
+
+// Fills parameters from codec context, e.g. from opened encoder.
+func (this *CodecParameters) FromContext(cc *CodecCtx) error {
+	if averr := C.avcodec_parameters_from_context(this.avCodecPar, cc.avCodecCtx); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to copy codec parameters from context: %s", AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// Copies parameters into codec context, e.g. to configure decoder.
+func (this *CodecParameters) ToContext(cc *CodecCtx) error {
+	if averr := C.avcodec_parameters_to_context(cc.avCodecCtx, this.avCodecPar); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to copy codec parameters to context: %s", AvError(int(averr))))
+	}
+
+	return nil
+}
+
+func (this *CodecParameters) CodecId() int {
+	return int(this.avCodecPar.codec_id)
+}
+
+func (this *CodecParameters) CodecType() int32 {
+	return int32(this.avCodecPar.codec_type)
+}
+
+func (this *CodecParameters) CodecTag() uint32 {
+	return uint32(this.avCodecPar.codec_tag)
+}
+
+func (this *CodecParameters) BitRate() int64 {
+	return int64(this.avCodecPar.bit_rate)
+}
+
+func (this *CodecParameters) Width() int {
+	return int(this.avCodecPar.width)
+}
+
+func (this *CodecParameters) Height() int {
+	return int(this.avCodecPar.height)
+}
+
+// AVPixelFormat for video, AVSampleFormat for audio
+func (this *CodecParameters) Format() int32 {
+	return int32(this.avCodecPar.format)
+}
+
+func (this *CodecParameters) SampleRate() int {
+	return int(this.avCodecPar.sample_rate)
+}
+
+func (this *CodecParameters) Channels() int {
+	return int(this.avCodecPar.channels)
+}
+
+func (this *CodecParameters) ChannelLayout() int64 {
+	return int64(this.avCodecPar.channel_layout)
+}
+
+// Parameters which belong to stream are freed with the format context.
+func (this *CodecParameters) Free() {
+	if this.owned {
+		C.avcodec_parameters_free(&this.avCodecPar)
+	}
+}
@@ -54,3 +54,68 @@ func TestCodec(t *testing.T) {
 
 	log.Printf("%d encoders, %d decoders checked. %d not found", enc, dec, notfound)
 }
+
+func TestCodecSupportedFormats(t *testing.T) {
+	mpeg4 := assert(FindEncoder("mpeg4")).(*Codec)
+
+	found := false
+	for _, f := range mpeg4.SupportedPixelFormats() {
+		if f == AV_PIX_FMT_YUV420P {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("Expected mpeg4 to support yuv420p, %v got\n", mpeg4.SupportedPixelFormats())
+	}
+
+	mp2 := assert(FindEncoder("mp2")).(*Codec)
+
+	if fmts := mp2.SupportedSampleFormats(); len(fmts) == 0 || fmts[0] != AV_SAMPLE_FMT_S16 {
+		t.Fatalf("Expected mp2 to support s16, %v got\n", fmts)
+	}
+
+	if rates := mp2.SupportedSampleRates(); len(rates) == 0 {
+		t.Fatal("Expected mp2 sample rates")
+	}
+
+	if layouts := mp2.SupportedChannelLayouts(); len(layouts) == 0 {
+		t.Fatal("Expected mp2 channel layouts")
+	}
+
+	log.Println("mp2 sample rates:", mp2.SupportedSampleRates())
+}
+
+func TestFindCodecByName(t *testing.T) {
+	enc, err := FindEncoderByName("mpeg4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if enc.Id() != AV_CODEC_ID_MPEG4 {
+		t.Fatalf("Expected codec id %d, %d got\n", AV_CODEC_ID_MPEG4, enc.Id())
+	}
+
+	if _, err := FindDecoderByName("h264"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := FindEncoderByName("not-existing-encoder"); err == nil {
+		t.Fatal("Expected error for unknown encoder")
+	}
+}
+
+func TestCodecCapabilities(t *testing.T) {
+	codec, err := FindEncoder("pcm_s16le")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !codec.HasCapability(AV_CODEC_CAP_VARIABLE_FRAME_SIZE) {
+		t.Fatalf("Expected pcm encoder to support variable frame size, capabilities %d got\n", codec.Capabilities())
+	}
+
+	if codec.HasCapability(AV_CODEC_CAP_VARIABLE_FRAME_SIZE | AV_CODEC_CAP_EXPERIMENTAL) {
+		t.Fatal("Expected pcm encoder not to be experimental")
+	}
+}
@@ -4,13 +4,17 @@ package gmf
 
 #cgo pkg-config: libavutil
 
+#include <stdlib.h>
 #include "libavutil/dict.h"
 
 */
 import "C"
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"unsafe"
 )
 
 type Pair struct {
@@ -20,22 +24,80 @@ type Pair struct {
 
 type Dict struct {
 	avDict *C.struct_AVDictionary
+	CgoMemoryManage
 }
 
 func NewDict(pairs []Pair) *Dict {
 	this := &Dict{avDict: nil}
 
 	for _, pair := range pairs {
-		ckey := C.CString(pair.Key)
-		cval := C.CString(pair.Val)
+		if err := this.Set(pair.Key, pair.Val); err != nil {
+			log.Println(err)
+		}
+	}
+
+	return this
+}
+
+// Creates a copy of AVDictionary, so Dict can be released independently.
+func newDictFromAVDict(avDict *C.struct_AVDictionary) *Dict {
+	this := &Dict{avDict: nil}
+
+	C.av_dict_copy(&this.avDict, avDict, 0)
+
+	return this
+}
+
+func (this *Dict) Set(key, val string) error {
+	ckey := C.CString(key)
+	defer C.free(unsafe.Pointer(ckey))
+
+	cval := C.CString(val)
+	defer C.free(unsafe.Pointer(cval))
+
+	if ret := C.av_dict_set(&this.avDict, ckey, cval, 0); int(ret) < 0 {
+		return errors.New(fmt.Sprintf("unable to set key '%s' value '%s', error: %s", key, val, AvError(int(ret))))
+	}
 
-		if ret := C.av_dict_set(&this.avDict, ckey, cval, 0); int(ret) < 0 {
-			log.Printf("unable to set key '%s' value '%d', error: %s\n", pair.Key, pair.Val, AvError(int(ret)))
+	return nil
+}
+
+// Returns empty string if key is not found.
+func (this *Dict) Get(key string) string {
+	ckey := C.CString(key)
+	defer C.free(unsafe.Pointer(ckey))
+
+	entry := C.av_dict_get(this.avDict, ckey, nil, 0)
+	if entry == nil {
+		return ""
+	}
+
+	return C.GoString(entry.value)
+}
+
+func (this *Dict) Count() int {
+	return int(C.av_dict_count(this.avDict))
+}
+
+func (this *Dict) Pairs() []Pair {
+	var entry *C.AVDictionaryEntry
+
+	empty := C.CString("")
+	defer C.free(unsafe.Pointer(empty))
+
+	result := make([]Pair, 0, this.Count())
+
+	for {
+		if entry = C.av_dict_get(this.avDict, empty, entry, C.AV_DICT_IGNORE_SUFFIX); entry == nil {
+			break
 		}
 
-		// C.free(unsafe.Pointer(ckey))
-		// C.free(unsafe.Pointer(cval))
+		result = append(result, Pair{Key: C.GoString(entry.key), Val: C.GoString(entry.value)})
 	}
 
-	return this
+	return result
+}
+
+func (this *Dict) Free() {
+	C.av_dict_free(&this.avDict)
 }
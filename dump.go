@@ -0,0 +1,153 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavformat libavcodec
+
+#include "libavformat/avformat.h"
+#include "libavcodec/avcodec.h"
+
+static void gmf_stream_string(AVStream *st, char *buf, int size, int encoder) {
+	AVCodecContext *cc;
+
+	buf[0] = 0;
+
+	if (!(cc = avcodec_alloc_context3(NULL))) {
+		return;
+	}
+
+	if (avcodec_parameters_to_context(cc, st->codecpar) >= 0) {
+		avcodec_string(buf, size, cc, encoder);
+	}
+
+	avcodec_free_context(&cc);
+}
+
+*/
+import "C"
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+	"unsafe"
+)
+
+// Writes format and streams description to w, in the same manner as av_dump_format does.
+// Unlike Dump, output doesn't go through av_log.
+func (this *FmtCtx) DumpFormat(w io.Writer) {
+	if this.avCtx == nil {
+		return
+	}
+
+	isOutput := this.avCtx.oformat != nil
+
+	var name string
+	if isOutput {
+		fmt.Fprintf(w, "Output #0, %s, to '%s':\n", C.GoString(this.avCtx.oformat.name), this.Filename)
+	} else {
+		if this.avCtx.iformat != nil {
+			name = C.GoString(this.avCtx.iformat.name)
+		}
+		fmt.Fprintf(w, "Input #0, %s, from '%s':\n", name, this.Filename)
+	}
+
+	dumpMetadata(w, this.Metadata(), "  ")
+
+	if !isOutput {
+		fmt.Fprintf(w, "  Duration: %s", formatDumpDuration(this.avCtx.duration))
+
+		if int64(this.avCtx.start_time) != AV_NOPTS_VALUE {
+			fmt.Fprintf(w, ", start: %f", float64(this.avCtx.start_time)/float64(AV_TIME_BASE))
+		}
+
+		if this.avCtx.bit_rate > 0 {
+			fmt.Fprintf(w, ", bitrate: %d kb/s\n", int64(this.avCtx.bit_rate)/1000)
+		} else {
+			fmt.Fprintf(w, ", bitrate: N/A\n")
+		}
+	}
+
+	buf := (*C.char)(C.av_malloc(256))
+	defer C.av_free(unsafe.Pointer(buf))
+
+	for i := 0; i < this.StreamsCnt(); i++ {
+		st, err := this.GetStream(i)
+		if err != nil {
+			continue
+		}
+
+		var encoder C.int
+		if isOutput {
+			encoder = 1
+		}
+
+		C.gmf_stream_string(st.avStream, buf, 256, encoder)
+
+		meta := st.Metadata()
+		lang := ""
+		if l := meta.Get("language"); l != "" {
+			lang = "(" + l + ")"
+		}
+
+		fmt.Fprintf(w, "    Stream #0:%d%s: %s", i, lang, C.GoString(buf))
+
+		// codec parameters are used, Stream.IsVideo would open decoder
+		if st.avStream.codecpar.codec_type == C.AVMEDIA_TYPE_VIDEO {
+			if fr := st.avStream.avg_frame_rate; fr.den != 0 && fr.num != 0 {
+				fmt.Fprintf(w, ", %.4g fps", float64(fr.num)/float64(fr.den))
+			}
+
+			if tb := st.avStream.time_base; tb.num != 0 {
+				fmt.Fprintf(w, ", %.4g tbn", float64(tb.den)/float64(tb.num))
+			}
+		}
+
+		fmt.Fprintf(w, "\n")
+
+		dumpMetadata(w, meta, "      ")
+	}
+}
+
+// Returns the same output as DumpFormat.
+func (this *FmtCtx) DumpFormatString() string {
+	var b bytes.Buffer
+
+	this.DumpFormat(&b)
+
+	return b.String()
+}
+
+func dumpMetadata(w io.Writer, d *Dict, indent string) {
+	defer Release(d)
+
+	pairs := d.Pairs()
+	if len(pairs) == 0 || (len(pairs) == 1 && pairs[0].Key == "language") {
+		return
+	}
+
+	fmt.Fprintf(w, "%sMetadata:\n", indent)
+
+	for _, p := range pairs {
+		if p.Key == "language" {
+			continue
+		}
+		fmt.Fprintf(w, "%s  %-16s: %s\n", indent, p.Key, p.Val)
+	}
+}
+
+func formatDumpDuration(d C.int64_t) string {
+	if int64(d) == AV_NOPTS_VALUE {
+		return "N/A"
+	}
+
+	dur := toDuration(int64(d), AV_TIME_BASE_Q)
+
+	h := dur / time.Hour
+	m := (dur % time.Hour) / time.Minute
+	s := (dur % time.Minute) / time.Second
+	cs := (dur % time.Second) / (10 * time.Millisecond)
+
+	return fmt.Sprintf("%02d:%02d:%02d.%02d", h, m, s, cs)
+}
@@ -0,0 +1,50 @@
+package gmf
+
+import (
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestDumpFormatString(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	dump := inputCtx.DumpFormatString()
+
+	for _, s := range []string{"Input #0, mov,mp4", inputSampleFilename, "Duration: ", "Stream #0:0"} {
+		if !strings.Contains(dump, s) {
+			t.Fatalf("Expected dump to contain '%s', '%s' got\n", s, dump)
+		}
+	}
+
+	log.Println(dump)
+}
+
+func TestDumpFormatOutput(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
+
+	outputCtx := assert(NewOutputCtxWithFormatName("", "mpegts")).(*FmtCtx)
+	defer Release(outputCtx)
+
+	if _, err := outputCtx.AddStreamWithCodecPar(ist.CodecPar()); err != nil {
+		t.Fatal(err)
+	}
+
+	dump := outputCtx.DumpFormatString()
+
+	for _, s := range []string{"Output #0, mpegts", "Stream #0:0"} {
+		if !strings.Contains(dump, s) {
+			t.Fatalf("Expected dump to contain '%s', '%s' got\n", s, dump)
+		}
+	}
+
+	inputCtx.DumpFormatString()
+
+	if ist.IsCodecCtxSet() {
+		t.Fatal("Expected dump not to open decoder of input stream")
+	}
+}
@@ -0,0 +1,253 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavfilter libavutil
+
+#include <stdlib.h>
+
+#include "libavfilter/avfilter.h"
+#include "libavfilter/buffersrc.h"
+#include "libavfilter/buffersink.h"
+#include "libavutil/mem.h"
+
+static AVFilterInOut *gmf_inout(const char *name, AVFilterContext *ctx) {
+	AVFilterInOut *io = avfilter_inout_alloc();
+	if (!io) {
+		return NULL;
+	}
+
+	io->name       = av_strdup(name);
+	io->filter_ctx = ctx;
+	io->pad_idx    = 0;
+	io->next       = NULL;
+
+	return io;
+}
+
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+func init() {
+	C.avfilter_register_all()
+}
+
+type FilterContext struct {
+	avFilterCtx *C.struct_AVFilterContext
+}
+
+func (this *FilterContext) Name() string {
+	return C.GoString(this.avFilterCtx.name)
+}
+
+// Filter graph with one buffer source and one buffer sink.
+//
+// E.g.:
+//
+//	fg := NewFilterGraph()
+//	fg.AddBufferSource(decoderCtx)
+//	fg.AddBufferSink()
+//	fg.Parse("scale=640:480,fps=30")
+//
+//	fg.Push(frame)
+//	filtered, err := fg.Pull()
+type FilterGraph struct {
+	avGraph   *C.struct_AVFilterGraph
+	src       *FilterContext
+	sink      *FilterContext
+	mediaType int32
+	CgoMemoryManage
+}
+
+func NewFilterGraph() *FilterGraph {
+	graph := C.avfilter_graph_alloc()
+	if graph == nil {
+		return nil
+	}
+
+	return &FilterGraph{avGraph: graph}
+}
+
+func (this *FilterGraph) createFilter(filterName, name, args string) (*FilterContext, error) {
+	var avFilterCtx *C.struct_AVFilterContext
+
+	cfilterName := C.CString(filterName)
+	defer C.free(unsafe.Pointer(cfilterName))
+
+	filter := C.avfilter_get_by_name(cfilterName)
+	if filter == nil {
+		return nil, errors.New(fmt.Sprintf("Unable to find filter '%s'", filterName))
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	var cargs *C.char
+
+	if args != "" {
+		cargs = C.CString(args)
+		defer C.free(unsafe.Pointer(cargs))
+	}
+
+	if averr := C.avfilter_graph_create_filter(&avFilterCtx, filter, cname, cargs, nil, this.avGraph); averr < 0 {
+		return nil, errors.New(fmt.Sprintf("Unable to create filter '%s': %s", filterName, AvError(int(averr))))
+	}
+
+	return &FilterContext{avFilterCtx: avFilterCtx}, nil
+}
+
+// Adds graph input, configured by the parameters of decoder context cc.
+func (this *FilterGraph) AddBufferSource(cc *CodecCtx) (*FilterContext, error) {
+	var filterName, args string
+
+	tb := cc.TimeBase().AVR()
+
+	switch cc.Type() {
+	case AVMEDIA_TYPE_VIDEO:
+		sar := AVRational(cc.avCodecCtx.sample_aspect_ratio).AVR()
+		if sar.Den == 0 {
+			sar = AVR{0, 1}
+		}
+
+		filterName = "buffer"
+		args = fmt.Sprintf("video_size=%dx%d:pix_fmt=%d:time_base=%d/%d:pixel_aspect=%d/%d",
+			cc.Width(), cc.Height(), cc.PixFmt(), tb.Num, tb.Den, sar.Num, sar.Den)
+
+	case AVMEDIA_TYPE_AUDIO:
+		filterName = "abuffer"
+		args = fmt.Sprintf("time_base=%d/%d:sample_rate=%d:sample_fmt=%s:channel_layout=0x%x",
+			tb.Num, tb.Den, cc.SampleRate(), GetSampleFmtName(cc.SampleFmt()), cc.ChannelLayout())
+
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported media type: %v", cc.Type()))
+	}
+
+	src, err := this.createFilter(filterName, "in", args)
+	if err != nil {
+		return nil, err
+	}
+
+	this.src = src
+	this.mediaType = cc.Type()
+
+	return src, nil
+}
+
+// Adds graph output. Buffer source should be added before.
+func (this *FilterGraph) AddBufferSink() (*FilterContext, error) {
+	var filterName string
+
+	switch this.mediaType {
+	case AVMEDIA_TYPE_VIDEO:
+		filterName = "buffersink"
+
+	case AVMEDIA_TYPE_AUDIO:
+		filterName = "abuffersink"
+
+	default:
+		return nil, errors.New("buffer source is not initialized")
+	}
+
+	sink, err := this.createFilter(filterName, "out", "")
+	if err != nil {
+		return nil, err
+	}
+
+	this.sink = sink
+
+	return sink, nil
+}
+
+// Links buffer source and sink with the filter chain described by desc,
+// e.g. "scale=640:480,fps=30", and configures the graph.
+func (this *FilterGraph) Parse(desc string) error {
+	if this.src == nil || this.sink == nil {
+		return errors.New("buffer source and sink should be added before parsing")
+	}
+
+	cin := C.CString("in")
+	defer C.free(unsafe.Pointer(cin))
+
+	cout := C.CString("out")
+	defer C.free(unsafe.Pointer(cout))
+
+	// outputs of the source are the inputs of parsed chain and vice versa
+	outputs := C.gmf_inout(cin, this.src.avFilterCtx)
+	inputs := C.gmf_inout(cout, this.sink.avFilterCtx)
+	defer C.avfilter_inout_free(&outputs)
+	defer C.avfilter_inout_free(&inputs)
+
+	if outputs == nil || inputs == nil {
+		return errors.New("unable to allocate filter inputs/outputs")
+	}
+
+	cdesc := C.CString(desc)
+	defer C.free(unsafe.Pointer(cdesc))
+
+	if averr := C.avfilter_graph_parse_ptr(this.avGraph, cdesc, &inputs, &outputs, nil); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to parse filter graph '%s': %s", desc, AvError(int(averr))))
+	}
+
+	if averr := C.avfilter_graph_config(this.avGraph, nil); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to configure filter graph '%s': %s", desc, AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// Pushes frame into the graph, frame itself stays owned by caller.
+// Nil frame signals the end of stream.
+func (this *FilterGraph) Push(f *Frame) error {
+	var avFrame *C.struct_AVFrame
+
+	if f != nil {
+		avFrame = f.avFrame
+	}
+
+	if averr := C.av_buffersrc_add_frame_flags(this.src.avFilterCtx, avFrame, C.AV_BUFFERSRC_FLAG_KEEP_REF); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to push frame into filter graph: %s", AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// Pulls filtered frame from the graph. It returns nil frame and nil error, if graph
+// needs more input, and io.EOF, when the graph is drained.
+func (this *FilterGraph) Pull() (*Frame, error) {
+	frame := NewFrame()
+	frame.mediaType = this.mediaType
+
+	if averr := int(C.av_buffersink_get_frame(this.sink.avFilterCtx, frame.avFrame)); averr < 0 {
+		Release(frame)
+
+		if averr == AVERROR_EAGAIN {
+			return nil, nil
+		}
+
+		if averr == AVERROR_EOF {
+			return nil, io.EOF
+		}
+
+		return nil, errors.New(fmt.Sprintf("Unable to pull frame from filter graph: %s", AvError(averr)))
+	}
+
+	return frame, nil
+}
+
+func (this *FilterGraph) Dump() string {
+	graph := C.avfilter_graph_dump(this.avGraph, nil)
+	defer C.av_free(unsafe.Pointer(graph))
+
+	return C.GoString(graph)
+}
+
+func (this *FilterGraph) Free() {
+	C.avfilter_graph_free(&this.avGraph)
+}
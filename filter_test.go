@@ -0,0 +1,73 @@
+package gmf
+
+import (
+	"log"
+	"testing"
+)
+
+func TestFilterGraph(t *testing.T) {
+	codec, err := FindEncoder("mpeg4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cc := NewCodecCtx(codec)
+	defer Release(cc)
+
+	cc.SetDimension(320, 200).SetTimeBase(AVR{1, 25}).SetPixFmt(AV_PIX_FMT_YUV420P)
+
+	fg := NewFilterGraph()
+	if fg == nil {
+		t.Fatal("Unable to allocate filter graph")
+	}
+	defer Release(fg)
+
+	if _, err := fg.AddBufferSource(cc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fg.AddBufferSink(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fg.Parse("scale=160:100"); err != nil {
+		t.Fatal(err)
+	}
+
+	i := int64(0)
+	filtered := 0
+
+	for frame := range GenSyntVideoNewFrame(320, 200, AV_PIX_FMT_YUV420P) {
+		frame.SetPts(i)
+		i++
+
+		if err := fg.Push(frame); err != nil {
+			t.Fatal(err)
+		}
+		Release(frame)
+
+		for {
+			out, err := fg.Pull()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out == nil {
+				break
+			}
+
+			if out.Width() != 160 || out.Height() != 100 {
+				t.Fatalf("Expected dimension = 160x100, %dx%d got\n", out.Width(), out.Height())
+			}
+
+			filtered++
+			Release(out)
+		}
+	}
+
+	if filtered == 0 {
+		t.Fatal("Expected filtered frames > 0")
+	}
+
+	log.Println(filtered, "frames filtered")
+}
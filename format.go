@@ -6,6 +6,7 @@ package gmf
 #cgo pkg-config: libavformat libavdevice
 
 #include <stdlib.h>
+#include <errno.h>
 #include "libavformat/avformat.h"
 #include <libavdevice/avdevice.h>
 #include "libavutil/opt.h"
@@ -14,6 +15,13 @@ static AVStream* gmf_get_stream(AVFormatContext *ctx, int idx) {
 	return ctx->streams[idx];
 }
 
+static int gmf_missing_global_header(AVStream *st) {
+	AVCodecContext *c = st->codec;
+
+	return c && avcodec_is_open(c) && c->codec && av_codec_is_encoder(c->codec) &&
+		!(c->flags & AV_CODEC_FLAG_GLOBAL_HEADER);
+}
+
 static int gmf_alloc_priv_data(AVFormatContext *s, AVDictionary **options) {
 	AVDictionary *tmp = NULL;
 
@@ -49,13 +57,39 @@ import "C"
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 	"unsafe"
 )
 
 var (
 	AVFMT_FLAG_GENPTS int = C.AVFMT_FLAG_GENPTS
 	AVFMTCTX_NOHEADER int = C.AVFMTCTX_NOHEADER
+
+	// Format context flags for SetFlag. Demuxer flags should be set before OpenInput,
+	// muxer ones before WriteHeader.
+	AVFMT_FLAG_IGNIDX          int = C.AVFMT_FLAG_IGNIDX
+	AVFMT_FLAG_NONBLOCK        int = C.AVFMT_FLAG_NONBLOCK
+	AVFMT_FLAG_IGNDTS          int = C.AVFMT_FLAG_IGNDTS
+	AVFMT_FLAG_NOFILLIN        int = C.AVFMT_FLAG_NOFILLIN
+	AVFMT_FLAG_NOPARSE         int = C.AVFMT_FLAG_NOPARSE
+	AVFMT_FLAG_NOBUFFER        int = C.AVFMT_FLAG_NOBUFFER
+	AVFMT_FLAG_DISCARD_CORRUPT int = C.AVFMT_FLAG_DISCARD_CORRUPT
+	AVFMT_FLAG_FLUSH_PACKETS   int = C.AVFMT_FLAG_FLUSH_PACKETS
+	AVFMT_FLAG_BITEXACT        int = C.AVFMT_FLAG_BITEXACT
+	AVFMT_FLAG_SORT_DTS        int = C.AVFMT_FLAG_SORT_DTS
+
+	AVSEEK_FLAG_BACKWARD int = C.AVSEEK_FLAG_BACKWARD
+	AVSEEK_FLAG_BYTE     int = C.AVSEEK_FLAG_BYTE
+	AVSEEK_FLAG_ANY      int = C.AVSEEK_FLAG_ANY
+	AVSEEK_FLAG_FRAME    int = C.AVSEEK_FLAG_FRAME
+)
+
+var (
+	ErrSeekBeyondEOF  = errors.New("seek position is beyond the end of stream")
+	ErrNoFrameInRange = errors.New("no frame found in requested range")
 )
 
 const (
@@ -71,11 +105,19 @@ const (
 )
 
 type FmtCtx struct {
-	avCtx    *C.struct_AVFormatContext
-	Filename string
-	ofmt     *OutputFmt
-	streams  map[int]*Stream
-	customPb bool
+	// accessed atomically, should be first for 64-bit alignment
+	ioStart int64
+
+	avCtx          *C.struct_AVFormatContext
+	Filename       string
+	ofmt           *OutputFmt
+	streams        map[int]*Stream
+	customPb       bool
+	avioCtx        *AVIOContext
+	interruptKey   uintptr
+	err            error
+	trailerWritten bool
+	onProgress     func(pts int64, pos int64)
 	CgoMemoryManage
 }
 
@@ -157,6 +199,44 @@ func NewOutputCtxWithFormatName(filename, format string) (*FmtCtx, error) {
 	return this, nil
 }
 
+// Returns hex digest from output of "md5" or "hash" muxer, e.g. "MD5=..." line,
+// written to NewOutputCtxToWriter writer, after CloseOutputAndRelease.
+func HashFromOutput(data []byte) (string, error) {
+	line := strings.TrimSpace(string(data))
+
+	if i := strings.IndexByte(line, '='); i > 0 && i < len(line)-1 && !strings.ContainsAny(line, "\n,") {
+		return line[i+1:], nil
+	}
+
+	return "", errors.New(fmt.Sprintf("unexpected hash muxer output: '%s'", line))
+}
+
+// Creates output context of format, which writes muxed data to w. If w implements io.Seeker,
+// output is seekable, otherwise formats requiring seeking, e.g. plain mp4, fail on WriteHeader.
+func NewOutputCtxToWriter(w io.Writer, format string) (*FmtCtx, error) {
+	ctx, err := NewOutputCtxWithFormatName("", format)
+	if err != nil {
+		return nil, err
+	}
+
+	handlers := &AVIOHandlers{write: writerHandler(w)}
+
+	if seeker, ok := w.(io.Seeker); ok {
+		handlers.Seek = seekerHandler(seeker)
+	}
+
+	avioCtx, err := NewAVIOContext(ctx, handlers)
+	if err != nil {
+		Release(ctx)
+		return nil, err
+	}
+
+	ctx.SetPb(avioCtx)
+	ctx.avioCtx = avioCtx
+
+	return ctx, nil
+}
+
 // Just a helper for NewCtx().OpenInput()
 func NewInputCtx(filename string) (*FmtCtx, error) {
 	ctx := NewCtx()
@@ -186,8 +266,90 @@ func NewInputCtxWithFormatName(filename, format string) (*FmtCtx, error) {
 	}
 	return ctx, nil
 }
+
+// Opens input context, which reads data from r. If format is empty, it will be probed.
+// If r implements io.Seeker, the context is seekable.
+// AVIOContext is owned by the context and released by CloseInputAndRelease,
+// r itself is not closed.
+func NewInputCtxFromReader(r io.Reader, format string) (*FmtCtx, error) {
+	ctx := NewCtx()
+
+	if ctx.avCtx == nil {
+		return nil, errors.New(fmt.Sprintf("unable to allocate context"))
+	}
+
+	if format != "" {
+		if err := ctx.SetInputFormat(format); err != nil {
+			ctx.CloseInputAndRelease()
+			return nil, err
+		}
+	}
+
+	handlers := &AVIOHandlers{ReadPacket: readerHandler(r)}
+
+	if seeker, ok := r.(io.Seeker); ok {
+		handlers.Seek = seekerHandler(seeker)
+	}
+
+	avioCtx, err := NewAVIOContext(ctx, handlers)
+	if err != nil {
+		ctx.CloseInputAndRelease()
+		return nil, err
+	}
+
+	ctx.SetPb(avioCtx)
+	ctx.avioCtx = avioCtx
+
+	if err := ctx.OpenInput(""); err != nil {
+		ctx.CloseInputAndRelease()
+		return nil, err
+	}
+
+	return ctx, nil
+}
+
+// Same as NewInputCtx, but passes opts to demuxer and protocol, e.g. "rtsp_transport" or "timeout".
+// After the call opts contains only options, which were not consumed.
+func NewInputCtxWithOptions(filename string, opts *Dict) (*FmtCtx, error) {
+	ctx := NewCtx()
+
+	if ctx.avCtx == nil {
+		return nil, errors.New(fmt.Sprintf("unable to allocate context"))
+	}
+
+	if err := ctx.openInput(filename, opts); err != nil {
+		return nil, err
+	}
+
+	return ctx, nil
+}
+
 func (this *FmtCtx) OpenInput(filename string) error {
+	return this.openInput(filename, nil)
+}
+
+// Same as OpenInput, but passes opts to demuxer and protocol, e.g. "reconnect" or "timeout".
+// After the call opts contains only options, which were not consumed.
+func (this *FmtCtx) OpenInputWithOptions(url string, opts *Dict) error {
+	return this.openInput(url, opts)
+}
+
+// Returns short name of demuxer, e.g. "mov,mp4,m4a,3gp,3g2,mj2", or empty string if input isn't opened.
+func (this *FmtCtx) InputFormatName() string {
+	if this.avCtx == nil || this.avCtx.iformat == nil {
+		return ""
+	}
+
+	return C.GoString(this.avCtx.iformat.name)
+}
+
+func (this *FmtCtx) openInput(filename string, opts *Dict) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
 	var cfilename *_Ctype_char
+	var avDict **C.struct_AVDictionary
 
 	if filename == "" {
 		cfilename = nil
@@ -196,7 +358,13 @@ func (this *FmtCtx) OpenInput(filename string) error {
 		defer C.free(unsafe.Pointer(cfilename))
 	}
 
-	if averr := C.avformat_open_input(&this.avCtx, cfilename, nil, nil); averr < 0 {
+	if opts != nil {
+		avDict = &opts.avDict
+	}
+
+	this.touchIO()
+
+	if averr := C.avformat_open_input(&this.avCtx, cfilename, nil, avDict); averr < 0 {
 		return errors.New(fmt.Sprintf("Error opening input '%s': %s", filename, AvError(int(averr))))
 	}
 
@@ -211,6 +379,10 @@ func (this *FmtCtx) OpenInput(filename string) error {
 }
 
 func (this *FmtCtx) AddStreamWithCodeCtx(codeCtx *CodecCtx) (*Stream, error) {
+	if this.avCtx == nil {
+		return nil, ErrNilContext
+	}
+
 	var ost *Stream
 
 	// Create Video stream in output context
@@ -228,6 +400,49 @@ func (this *FmtCtx) AddStreamWithCodeCtx(codeCtx *CodecCtx) (*Stream, error) {
 	return ost, nil
 }
 
+// Creates new stream with a copy of par, e.g. input stream parameters for remuxing.
+func (this *FmtCtx) AddStreamWithCodecPar(par *CodecParameters) (*Stream, error) {
+	if this.avCtx == nil {
+		return nil, ErrNilContext
+	}
+
+	var ost *Stream
+
+	if ost = this.NewStream(nil); ost == nil {
+		return nil, errors.New(fmt.Sprintf("unable to create stream in context, filename: %s", this.Filename))
+	}
+
+	if averr := C.avcodec_parameters_copy(ost.avStream.codecpar, par.avCodecPar); averr < 0 {
+		return nil, errors.New(fmt.Sprintf("unable to copy codec parameters: %s", AvError(int(averr))))
+	}
+
+	return ost, nil
+}
+
+// Creates output stream for remuxing src: copies its codec parameters, time base, disposition
+// and metadata, e.g. language. Codec tag is reset, so muxer picks the valid one.
+func (this *FmtCtx) CopyStream(src *Stream) (*Stream, error) {
+	if this.avCtx == nil {
+		return nil, ErrNilContext
+	}
+
+	ost, err := this.AddStreamWithCodecPar(src.CodecPar())
+	if err != nil {
+		return nil, err
+	}
+
+	ost.avStream.codecpar.codec_tag = 0
+	ost.SetTimeBase(src.TimeBase().AVR())
+	ost.SetDisposition(src.Disposition())
+
+	md := src.Metadata()
+	defer Release(md)
+
+	ost.SetMetadata(md)
+
+	return ost, nil
+}
+
 func (this *FmtCtx) CloseOutputAndRelease() {
 	if this.avCtx == nil || this.IsNoFile() {
 		return
@@ -238,44 +453,163 @@ func (this *FmtCtx) CloseOutputAndRelease() {
 		C.avio_close(this.avCtx.pb)
 	}
 
+	// context created by NewOutputCtxToWriter
+	if this.avioCtx != nil {
+		this.WriteTrailer()
+		C.avio_flush(this.avCtx.pb)
+		this.avCtx.pb = nil
+
+		Release(this.avioCtx)
+		this.avioCtx = nil
+	}
+
 	Release(this)
 }
 
-func (this *FmtCtx) WriteTrailer() {
-	C.av_write_trailer(this.avCtx)
+// Opens url for writing, e.g. "pipe:1" or "tcp://host:port", passing opts to protocol.
+// Should be called before WriteHeader, which otherwise opens the file itself.
+// After the call opts contains only options, which were not consumed.
+func (this *FmtCtx) OpenOutput(url string, opts *Dict) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if this.avCtx.pb != nil {
+		return errors.New("output is already opened")
+	}
+
+	curl := C.CString(url)
+	defer C.free(unsafe.Pointer(curl))
+
+	var avDict **C.struct_AVDictionary
+	if opts != nil {
+		avDict = &opts.avDict
+	}
+
+	if averr := C.avio_open2(&this.avCtx.pb, curl, C.AVIO_FLAG_WRITE, &this.avCtx.interrupt_callback, avDict); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to open '%s': %s", url, AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// Flushes and closes output opened by OpenOutput or WriteHeader. Trailer should be written before it.
+func (this *FmtCtx) CloseOutput() error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if this.customPb || this.avCtx.pb == nil {
+		return nil
+	}
+
+	if averr := C.avio_closep(&this.avCtx.pb); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to close '%s': %s", this.Filename, AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// Writes stream trailer, it is safe to call it more than once, calls after the first
+// successful one are no-op. CloseOutputAndRelease calls it as well.
+func (this *FmtCtx) WriteTrailer() error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if this.trailerWritten {
+		return nil
+	}
+
+	if averr := C.av_write_trailer(this.avCtx); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to write trailer to '%s': %s", this.Filename, AvError(int(averr))))
+	}
+
+	this.trailerWritten = true
+
+	return nil
 }
 
 func (this *FmtCtx) CloseInputAndRelease() {
+	this.releaseDecoders()
 	C.avformat_close_input(&this.avCtx)
+
+	if this.avioCtx != nil {
+		Release(this.avioCtx)
+		this.avioCtx = nil
+	}
+
 	Release(this)
 }
 
 func (this *FmtCtx) IsNoFile() bool {
-	return this.avCtx.oformat != nil && (this.avCtx.oformat.flags&C.AVFMT_NOFILE) != 0
+	return this.avCtx != nil && this.avCtx.oformat != nil && (this.avCtx.oformat.flags&C.AVFMT_NOFILE) != 0
 }
 
 func (this *FmtCtx) IsGlobalHeader() bool {
 	return this.avCtx != nil && this.avCtx.oformat != nil && (this.avCtx.oformat.flags&C.AVFMT_GLOBALHEADER) != 0
 }
 
+// Writes stream header. If output format requires global headers,
+// it fails for streams with encoders, opened without CODEC_FLAG_GLOBAL_HEADER.
 func (this *FmtCtx) WriteHeader() error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if err := this.checkGlobalHeader(); err != nil {
+		return err
+	}
 
 	cfilename := &(this.avCtx.filename[0])
-	// If NOFILE flag isn't set and we don't use custom IO, open it
-	if !this.IsNoFile() && !this.customPb {
+	// If NOFILE flag isn't set and we don't use custom IO or output opened by OpenOutput, open it
+	if !this.IsNoFile() && !this.customPb && this.avCtx.pb == nil {
 		if averr := C.avio_open(&this.avCtx.pb, cfilename, C.AVIO_FLAG_WRITE); averr < 0 {
 			return errors.New(fmt.Sprintf("Unable to open '%s': %s", this.Filename, AvError(int(averr))))
 		}
 	}
 
 	if averr := C.avformat_write_header(this.avCtx, nil); averr < 0 {
+		if this.avioCtx != nil && this.avCtx.pb.seekable == 0 {
+			return errors.New(fmt.Sprintf("Unable to write header to non-seekable output: %s", AvError(int(averr))))
+		}
+
 		return errors.New(fmt.Sprintf("Unable to write header to '%s': %s", this.Filename, AvError(int(averr))))
 	}
 
 	return nil
 }
 
-func (this *FmtCtx) WritePacket(p *Packet) error {
+// Returns error listing streams with opened encoders, which miss CODEC_FLAG_GLOBAL_HEADER
+// required by output format. The flag has effect only on Open, so it can't be fixed here.
+func (this *FmtCtx) checkGlobalHeader() error {
+	if !this.IsGlobalHeader() {
+		return nil
+	}
+
+	var streams []string
+
+	for i := 0; i < this.StreamsCnt(); i++ {
+		if C.gmf_missing_global_header(C.gmf_get_stream(this.avCtx, C.int(i))) != 0 {
+			streams = append(streams, fmt.Sprintf("#%d", i))
+		}
+	}
+
+	if len(streams) > 0 {
+		return errors.New(fmt.Sprintf("Unable to write header to '%s': format %s requires CODEC_FLAG_GLOBAL_HEADER, it's not set for stream(s) %s",
+			this.Filename, C.GoString(this.avCtx.oformat.name), strings.Join(streams, ", ")))
+	}
+
+	return nil
+}
+
+// Writes packet, buffering it to ensure correct interleaving of streams.
+// Packet is unreferenced, i.e. muxer takes ownership of its data.
+func (this *FmtCtx) WriteInterleaved(p *Packet) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
 	if averr := C.av_interleaved_write_frame(this.avCtx, &p.avPacket); averr < 0 {
 		return errors.New(fmt.Sprintf("Unable to write packet to '%s': %s", this.Filename, AvError(int(averr))))
 	}
@@ -283,6 +617,30 @@ func (this *FmtCtx) WritePacket(p *Packet) error {
 	return nil
 }
 
+// Writes packet directly, caller is responsible for correct interleaving.
+// Unlike WriteInterleaved packet data isn't taken by muxer.
+func (this *FmtCtx) Write(p *Packet) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if averr := C.av_write_frame(this.avCtx, &p.avPacket); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to write packet to '%s': %s", this.Filename, AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// The same as WriteInterleaved.
+func (this *FmtCtx) WritePacket(p *Packet) error {
+	return this.WriteInterleaved(p)
+}
+
+// The same as Write.
+func (this *FmtCtx) WritePacketNoInterleave(p *Packet) error {
+	return this.Write(p)
+}
+
 func (this *FmtCtx) SetOformat(ofmt *OutputFmt) error {
 	if ofmt == nil {
 		return errors.New("'ofmt' is not initialized.")
@@ -296,6 +654,9 @@ func (this *FmtCtx) SetOformat(ofmt *OutputFmt) error {
 }
 
 func (this *FmtCtx) Dump() {
+	if this.avCtx == nil {
+		return
+	}
 
 	if this.ofmt == nil {
 		C.av_dump_format(this.avCtx, 0, &(this.avCtx.filename[0]), 0)
@@ -305,34 +666,168 @@ func (this *FmtCtx) Dump() {
 }
 
 func (this *FmtCtx) DumpAv() {
+	if this.avCtx == nil {
+		return
+	}
+
 	fmt.Println("AVCTX:\n", this.avCtx, "\niformat:\n", this.avCtx.iformat)
 	fmt.Println("flags:", this.avCtx.flags)
 }
 
 func (this *FmtCtx) GetNextPacket() *Packet {
+	if this.avCtx == nil {
+		this.err = ErrNilContext
+		return nil
+	}
+
 	p := NewPacket()
 	for {
+		this.touchIO()
 
 		if ret := C.av_read_frame(this.avCtx, &p.avPacket); int(ret) < 0 {
+			this.setReadError(int(ret))
 			Release(p)
 			return nil
 		}
 
+		this.progress(p)
+
 		return p
 	}
 }
 
+// Reads next packet into p, replacing its previous content. Returns io.EOF at the end of input.
+func (this *FmtCtx) ReadPacket(p *Packet) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	C.av_packet_unref(&p.avPacket)
+
+	this.touchIO()
+
+	if ret := int(C.av_read_frame(this.avCtx, &p.avPacket)); ret < 0 {
+		this.setReadError(ret)
+		if this.err == nil {
+			return io.EOF
+		}
+
+		return this.err
+	}
+
+	this.progress(p)
+
+	return nil
+}
+
+// Sets function, which is called for every read packet with its pts in AV_TIME_BASE units
+// (AV_NOPTS_VALUE if it's unknown) and byte position in input (-1 if it's unknown).
+// Compare pts with Duration or pos with input size to get progress. Nil fn removes it.
+func (this *FmtCtx) OnProgress(fn func(pts int64, pos int64)) {
+	this.onProgress = fn
+}
+
+func (this *FmtCtx) progress(p *Packet) {
+	if this.onProgress == nil {
+		return
+	}
+
+	pts := p.Pts()
+	if !IsNoPts(pts) {
+		if ist, err := this.GetStream(p.StreamIndex()); err == nil {
+			pts = RescaleQ(pts, ist.TimeBase(), AV_TIME_BASE_Q)
+		}
+	}
+
+	this.onProgress(pts, p.Pos())
+}
+
+// Starts or resumes playing of network stream, e.g. RTSP.
+func (this *FmtCtx) ReadPlay() error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if averr := C.av_read_play(this.avCtx); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to play '%s': %s", this.Filename, AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// Pauses network stream, e.g. RTSP. It can be resumed by ReadPlay.
+func (this *FmtCtx) ReadPause() error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if averr := C.av_read_pause(this.avCtx); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to pause '%s': %s", this.Filename, AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// Returns error, which stopped packets reading, or nil if the end of input was reached.
+// For packets channel it's valid after the channel is closed.
+func (this *FmtCtx) Err() error {
+	return this.err
+}
+
+func (this *FmtCtx) setReadError(averr int) {
+	if averr == AVERROR_EOF {
+		this.err = nil
+		return
+	}
+
+	this.err = errors.New(fmt.Sprintf("Unable to read packet from '%s': %s", this.Filename, AvError(averr)))
+}
+
 func (this *FmtCtx) GetNewPackets() chan *Packet {
+	return this.readPackets(nil)
+}
+
+// The same as GetNewPackets, but packets are taken from pool. Return them by pool.Put instead of Release.
+func (this *FmtCtx) GetNewPacketsFromPool(pool *PacketPool) chan *Packet {
+	return this.readPackets(pool)
+}
+
+func (this *FmtCtx) readPackets(pool *PacketPool) chan *Packet {
 	yield := make(chan *Packet)
 
 	go func() {
+		this.err = nil
+
+		if this.avCtx == nil {
+			this.err = ErrNilContext
+			close(yield)
+			return
+		}
+
 		for {
-			p := NewPacket()
+			var p *Packet
+
+			if pool != nil {
+				p = pool.Get()
+			} else {
+				p = NewPacket()
+			}
+
+			this.touchIO()
 
 			if ret := C.av_read_frame(this.avCtx, &p.avPacket); int(ret) < 0 {
+				this.setReadError(int(ret))
+
+				if pool != nil {
+					pool.Put(p)
+				} else {
+					Release(p)
+				}
 				break
 			}
 
+			this.progress(p)
+
 			yield <- p
 		}
 
@@ -343,6 +838,10 @@ func (this *FmtCtx) GetNewPackets() chan *Packet {
 }
 
 func (this *FmtCtx) NewStream(c *Codec) *Stream {
+	if this.avCtx == nil {
+		return nil
+	}
+
 	var avCodec *C.struct_AVCodec = nil
 
 	if c != nil {
@@ -359,14 +858,70 @@ func (this *FmtCtx) NewStream(c *Codec) *Stream {
 
 }
 
+// Creates stream with new codec context attached to it. CODEC_FLAG_GLOBAL_HEADER is set
+// if output format requires it. Returned codec context should be released by caller.
+func (this *FmtCtx) NewStreamWithCodec(c *Codec) (*Stream, *CodecCtx, error) {
+	if this.avCtx == nil {
+		return nil, nil, ErrNilContext
+	}
+
+	if c == nil {
+		return nil, nil, errors.New("codec is not initialized")
+	}
+
+	ost := this.NewStream(c)
+	if ost == nil {
+		return nil, nil, errors.New(fmt.Sprintf("unable to create stream in context, filename: %s", this.Filename))
+	}
+
+	cc := NewCodecCtx(c)
+	if cc == nil {
+		return nil, nil, errors.New(fmt.Sprintf("unable to allocate codec context for %s", c.Name()))
+	}
+
+	if this.IsGlobalHeader() {
+		cc.SetFlag(CODEC_FLAG_GLOBAL_HEADER)
+	}
+
+	ost.SetCodecCtx(cc)
+	ost.cc = cc
+
+	return ost, cc, nil
+}
+
 // Original structure member is called instead of len(this.streams)
 // because there is no initialized Stream wrappers in input context.
 func (this *FmtCtx) StreamsCnt() int {
+	if this.avCtx == nil {
+		return 0
+	}
+
 	return int(this.avCtx.nb_streams)
 }
 
+// Same as StreamsCnt.
+func (this *FmtCtx) StreamCount() int {
+	return this.StreamsCnt()
+}
+
+// Returns all streams of the context, indexed by stream index.
+// Stream wrappers are cached by the context, the underlying AVStreams are freed with it.
+func (this *FmtCtx) Streams() []*Stream {
+	result := make([]*Stream, this.StreamsCnt())
+
+	for i := range result {
+		result[i], _ = this.GetStream(i)
+	}
+
+	return result
+}
+
 func (this *FmtCtx) GetStream(idx int) (*Stream, error) {
-	if idx > this.StreamsCnt() || this.StreamsCnt() == 0 {
+	if this.avCtx == nil {
+		return nil, ErrNilContext
+	}
+
+	if idx < 0 || idx >= this.StreamsCnt() {
 		return nil, errors.New(fmt.Sprintf("Stream index '%d' is out of range. There is only '%d' streams.", idx, this.StreamsCnt()))
 	}
 
@@ -379,7 +934,25 @@ func (this *FmtCtx) GetStream(idx int) (*Stream, error) {
 	return this.streams[idx], nil
 }
 
+// Guesses frame rate of the stream using its r_frame_rate, avg_frame_rate and frame, which can be nil.
+func (this *FmtCtx) GuessFrameRate(s *Stream, f *Frame) AVR {
+	if this.avCtx == nil || s == nil {
+		return AVR{}
+	}
+
+	var frame *C.struct_AVFrame
+	if f != nil {
+		frame = f.avFrame
+	}
+
+	return AVRational(C.av_guess_frame_rate(this.avCtx, s.avStream, frame)).AVR()
+}
+
 func (this *FmtCtx) GetBestStream(typ int32) (*Stream, error) {
+	if this.avCtx == nil {
+		return nil, ErrNilContext
+	}
+
 	idx := C.av_find_best_stream(this.avCtx, typ, -1, -1, nil, 0)
 	if int(idx) < 0 {
 		return nil, errors.New(fmt.Sprintf("stream type %d not found", typ))
@@ -388,7 +961,113 @@ func (this *FmtCtx) GetBestStream(typ int32) (*Stream, error) {
 	return this.GetStream(int(idx))
 }
 
+// Returns index of the n-th (starting from 0) stream of mediaType, e.g. the second audio track.
+func (this *FmtCtx) StreamIndex(mediaType int32, n int) (int, error) {
+	if this.avCtx == nil {
+		return -1, ErrNilContext
+	}
+
+	for i := 0; i < this.StreamsCnt(); i++ {
+		if int32(C.gmf_get_stream(this.avCtx, C.int(i)).codecpar.codec_type) != mediaType {
+			continue
+		}
+
+		if n == 0 {
+			return i, nil
+		}
+		n--
+	}
+
+	return -1, errors.New(fmt.Sprintf("stream type %d not found", mediaType))
+}
+
+// Returns all streams of mediaType in order of their indexes.
+func (this *FmtCtx) StreamsOfType(mediaType int32) []*Stream {
+	if this.avCtx == nil {
+		return nil
+	}
+
+	var result []*Stream
+
+	for i := 0; i < this.StreamsCnt(); i++ {
+		if int32(C.gmf_get_stream(this.avCtx, C.int(i)).codecpar.codec_type) == mediaType {
+			st, _ := this.GetStream(i)
+			result = append(result, st)
+		}
+	}
+
+	return result
+}
+
+// Finds the best stream of given type and returns it with opened decoder context,
+// created from stream codec parameters. Decoder context is also used by Stream.CodecCtx()
+// and returned by later calls. The stream keeps its own reference, which is released with
+// the context, so each returned decoder should be released by caller.
+func (this *FmtCtx) GetBestStreamWithDecoder(typ int32) (*Stream, *CodecCtx, error) {
+	if this.avCtx == nil {
+		return nil, nil, ErrNilContext
+	}
+
+	var avCodec *C.struct_AVCodec
+
+	idx := C.av_find_best_stream(this.avCtx, typ, -1, -1, &avCodec, 0)
+	if int(idx) < 0 {
+		return nil, nil, errors.New(fmt.Sprintf("stream type %d not found: %s", typ, AvError(int(idx))))
+	}
+
+	st, err := this.GetStream(int(idx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if st.IsCodecCtxSet() {
+		Retain(st.cc)
+		return st, st.cc, nil
+	}
+
+	cc := NewCodecCtx(&Codec{avCodec: avCodec})
+	if cc == nil {
+		return nil, nil, errors.New("unable to allocate codec context")
+	}
+
+	if averr := C.avcodec_parameters_to_context(cc.avCodecCtx, st.avStream.codecpar); averr < 0 {
+		Release(cc)
+		return nil, nil, errors.New(fmt.Sprintf("unable to copy codec parameters: %s", AvError(int(averr))))
+	}
+
+	cc.avCodecCtx.time_base = st.avStream.time_base
+	cc.avCodecCtx.pkt_timebase = st.avStream.time_base
+
+	if err := cc.Open(nil); err != nil {
+		Release(cc)
+		return nil, nil, err
+	}
+
+	st.cc = cc
+	st.ccOwned = true
+
+	// one reference is kept by stream, another one is returned to caller
+	Retain(cc)
+
+	return st, cc, nil
+}
+
+// Releases decoders created by GetBestStreamWithDecoder.
+func (this *FmtCtx) releaseDecoders() {
+	for _, st := range this.streams {
+		if st.ccOwned {
+			Release(st.cc)
+			st.cc = nil
+			st.ccOwned = false
+		}
+	}
+}
+
 func (this *FmtCtx) FindStreamInfo() error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
 	if averr := C.avformat_find_stream_info(this.avCtx, nil); averr < 0 {
 		return errors.New(fmt.Sprintf("unable to find stream info: %s", AvError(int(averr))))
 	}
@@ -396,14 +1075,63 @@ func (this *FmtCtx) FindStreamInfo() error {
 	return nil
 }
 
+// Limits amount of data (in bytes) read while probing.
+// Set it on NewCtx() before OpenInput, to limit probing done by OpenInput as well.
+func (this *FmtCtx) SetProbeSize(val int64) *FmtCtx {
+	if this.avCtx == nil {
+		return this
+	}
+
+	this.avCtx.probesize = C.int64_t(val)
+	return this
+}
+
+// Limits duration (in AV_TIME_BASE units) of data analyzed while probing.
+// Set it on NewCtx() before OpenInput, to limit probing done by OpenInput as well.
+func (this *FmtCtx) SetAnalyzeDuration(val int64) *FmtCtx {
+	if this.avCtx == nil {
+		return this
+	}
+
+	this.avCtx.max_analyze_duration = C.int64_t(val)
+	return this
+}
+
+// Same as FindStreamInfo, but with limited probe size (in bytes) and analyze duration
+// (in AV_TIME_BASE units). Zero value keeps current setting. Some streams could be missed,
+// if limits are too low.
+func (this *FmtCtx) FindStreamInfoWithOptions(probeSize, analyzeDuration int64) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if probeSize > 0 {
+		this.SetProbeSize(probeSize)
+	}
+
+	if analyzeDuration > 0 {
+		this.SetAnalyzeDuration(analyzeDuration)
+	}
+
+	return this.FindStreamInfo()
+}
+
+// Forces demuxer by its short name, e.g. "mov". Should be called before OpenInput.
 func (this *FmtCtx) SetInputFormat(name string) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
 	cname := C.CString(name)
 	defer C.free(unsafe.Pointer(cname))
 
-	if this.avCtx.iformat = (*C.struct_AVInputFormat)(C.av_find_input_format(cname)); this.avCtx.iformat == nil {
-		return errors.New("unable to find format for name: " + name)
+	iformat := (*C.struct_AVInputFormat)(C.av_find_input_format(cname))
+	if iformat == nil {
+		return errors.New(fmt.Sprintf("unknown input format '%s'", name))
 	}
 
+	this.avCtx.iformat = iformat
+
 	if int(C.gmf_alloc_priv_data(this.avCtx, nil)) < 0 {
 		return errors.New("unable to allocate priv_data")
 	}
@@ -411,21 +1139,89 @@ func (this *FmtCtx) SetInputFormat(name string) error {
 	return nil
 }
 
+// Returns a copy of container metadata, it should be released by caller.
+func (this *FmtCtx) Metadata() *Dict {
+	if this.avCtx == nil {
+		return newDictFromAVDict(nil)
+	}
+
+	return newDictFromAVDict(this.avCtx.metadata)
+}
+
+// Copies d into container metadata. Should be called before WriteHeader.
+func (this *FmtCtx) SetMetadata(d *Dict) {
+	if this.avCtx == nil || d == nil {
+		return
+	}
+
+	C.av_dict_copy(&this.avCtx.metadata, d.avDict, 0)
+}
+
 func (this *FmtCtx) Free() {
+	this.removeInterruptCallback()
+	this.releaseDecoders()
+
 	if this.avCtx != nil {
 		C.avformat_free_context(this.avCtx)
+		this.avCtx = nil
 	}
 }
 
-func (this *FmtCtx) Duration() int {
-	return int(this.avCtx.duration)
+// Returns 0 if duration is unknown.
+func (this *FmtCtx) Duration() time.Duration {
+	if this.avCtx == nil {
+		return 0
+	}
+
+	return toDuration(int64(this.avCtx.duration), AV_TIME_BASE_Q)
 }
 
-func (this *FmtCtx) StartTime() int {
-	return int(this.avCtx.start_time)
+// Returns total bit rate in bits per second. If container doesn't report it,
+// it's estimated from input size and duration. Returns 0 if it's unknown.
+func (this *FmtCtx) BitRate() int64 {
+	if this.avCtx == nil {
+		return 0
+	}
+
+	if this.avCtx.bit_rate > 0 {
+		return int64(this.avCtx.bit_rate)
+	}
+
+	if this.avCtx.pb == nil || this.avCtx.duration <= 0 || IsNoPts(int64(this.avCtx.duration)) {
+		return 0
+	}
+
+	size := int64(C.avio_size(this.avCtx.pb))
+	if size <= 0 {
+		return 0
+	}
+
+	return size * 8 * int64(AV_TIME_BASE) / int64(this.avCtx.duration)
 }
 
-func (this *FmtCtx) SetStartTime(val int) *FmtCtx {
+// Returns start time in AV_TIME_BASE units, AV_NOPTS_VALUE if it's unknown.
+func (this *FmtCtx) StartTime() int64 {
+	if this.avCtx == nil {
+		return 0
+	}
+
+	return int64(this.avCtx.start_time)
+}
+
+// Returns 0 if start time is unknown.
+func (this *FmtCtx) StartTimeDuration() time.Duration {
+	if this.avCtx == nil {
+		return 0
+	}
+
+	return toDuration(int64(this.avCtx.start_time), AV_TIME_BASE_Q)
+}
+
+func (this *FmtCtx) SetStartTime(val int64) *FmtCtx {
+	if this.avCtx == nil {
+		return this
+	}
+
 	this.avCtx.start_time = C.int64_t(val)
 	return this
 }
@@ -436,47 +1232,160 @@ func (this *FmtCtx) TsOffset(stime int) int {
 }
 
 func (this *FmtCtx) SetDebug(val int) *FmtCtx {
+	if this.avCtx == nil {
+		return this
+	}
+
 	this.avCtx.debug = C.int(val)
 	return this
 }
 
+// Sets AVFMT_FLAG_* flag. Input flags should be set before OpenInput,
+// output ones, e.g. AVFMT_FLAG_FLUSH_PACKETS, before WriteHeader.
 func (this *FmtCtx) SetFlag(flag int) *FmtCtx {
+	if this.avCtx == nil {
+		return this
+	}
+
 	this.avCtx.flags |= C.int(flag)
 	return this
 }
 
-func (this *FmtCtx) SeekFile(ist *Stream, minTs, maxTs int64, flag int) error {
-	if ret := int(C.avformat_seek_file(this.avCtx, C.int(ist.Index()), C.int64_t(0), C.int64_t(minTs), C.int64_t(maxTs), C.int(flag))); ret < 0 {
-		return errors.New(fmt.Sprintf("Error creating output context: %s", AvError(ret)))
+// Makes demuxer drop packets flagged as corrupt. Should be called before OpenInput.
+func (this *FmtCtx) SetDiscardCorrupt(val bool) *FmtCtx {
+	if this.avCtx == nil {
+		return this
+	}
+
+	if val {
+		this.avCtx.flags |= C.AVFMT_FLAG_DISCARD_CORRUPT
+	} else {
+		this.avCtx.flags &^= C.AVFMT_FLAG_DISCARD_CORRUPT
+	}
+
+	return this
+}
+
+// Sets generic or format private option, e.g. "movflags" to "+faststart" for mp4 muxer.
+// Muxer options should be set before WriteHeader, they have no effect after it.
+func (this *FmtCtx) SetOpt(name, value string) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	cvalue := C.CString(value)
+	defer C.free(unsafe.Pointer(cvalue))
+
+	averr := C.av_opt_set(unsafe.Pointer(this.avCtx), cname, cvalue, C.AV_OPT_SEARCH_CHILDREN)
+
+	if averr == C.AVERROR_OPTION_NOT_FOUND {
+		return errors.New(fmt.Sprintf("Option '%s' is unknown to format context '%s'", name, this.Filename))
+	}
+
+	if averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to set option '%s' to '%s': %s", name, value, AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// Seeks to timestamp ts, so that minTs <= ts <= maxTs.
+// If streamIndex is -1, default stream is used and timestamps are in AV_TIME_BASE units,
+// otherwise they are in time base of the stream.
+// ErrNoFrameInRange is returned, if seeking fails with AVERROR(EPERM), which is reported
+// when no frame is found within the range. It's a heuristic: the same code (-1) is used by
+// some demuxers for any failure, so the error can hide other causes.
+func (this *FmtCtx) SeekFile(streamIndex int, minTs, ts, maxTs int64, flags int) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if minTs > ts || ts > maxTs {
+		return errors.New(fmt.Sprintf("Invalid seek range: %d <= %d <= %d", minTs, ts, maxTs))
+	}
+
+	if ret := int(C.avformat_seek_file(this.avCtx, C.int(streamIndex), C.int64_t(minTs), C.int64_t(ts), C.int64_t(maxTs), C.int(flags))); ret < 0 {
+		if ret == -int(C.EPERM) {
+			return ErrNoFrameInRange
+		}
+
+		return errors.New(fmt.Sprintf("Unable to seek to %d in stream #%d: %s", ts, streamIndex, AvError(ret)))
 	}
 
 	return nil
 }
 
-func (this *FmtCtx) SeekFrameAt(sec int64, streamIndex int) error {
+// Seeks to the keyframe at timestamp ts, which is in ist time base units.
+// Decoder buffers of ist are flushed after seeking.
+func (this *FmtCtx) SeekFrame(ist *Stream, ts int64, flags int) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
+	if duration := int64(ist.avStream.duration); flags&AVSEEK_FLAG_BYTE == 0 && duration > 0 && duration != AV_NOPTS_VALUE {
+		start := ist.StartTime()
+		if IsNoPts(start) {
+			start = 0
+		}
+
+		if ts > start+duration {
+			return ErrSeekBeyondEOF
+		}
+	}
+
+	if ret := int(C.av_seek_frame(this.avCtx, C.int(ist.Index()), C.int64_t(ts), C.int(flags))); ret < 0 {
+		if ret == AVERROR_EOF {
+			return ErrSeekBeyondEOF
+		}
+
+		return errors.New(fmt.Sprintf("Unable to seek to %d in stream #%d: %s", ts, ist.Index(), AvError(ret)))
+	}
+
+	if ist.IsCodecCtxSet() {
+		ist.CodecCtx().FlushBuffers()
+	}
+
+	return nil
+}
+
+// Seeks to the nearest keyframe before d.
+func (this *FmtCtx) SeekFrameAt(d time.Duration, streamIndex int) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
 	ist, err := this.GetStream(streamIndex)
 	if err != nil {
 		return err
 	}
 
-	frameTs := Rescale(sec*1000, int64(ist.TimeBase().AVR().Den), int64(ist.TimeBase().AVR().Num)) / 1000
+	ts := RescaleQ(int64(d/time.Microsecond), AV_TIME_BASE_Q, ist.TimeBase())
 
-	if err := this.SeekFile(ist, frameTs, frameTs, C.AVSEEK_FLAG_FRAME); err != nil {
-		return err
+	if start := ist.StartTime(); !IsNoPts(start) {
+		ts += start
 	}
 
-	ist.CodecCtx().FlushBuffers()
-
-	return nil
+	return this.SeekFrame(ist, ts, AVSEEK_FLAG_BACKWARD)
 }
 
 func (this *FmtCtx) SetPb(val *AVIOContext) *FmtCtx {
+	if this.avCtx == nil {
+		return this
+	}
+
 	this.avCtx.pb = val.avAVIOContext
 	this.customPb = true
 	return this
 }
 
 func (this *FmtCtx) GetSDPString() (sdp string) {
+	if this.avCtx == nil {
+		return ""
+	}
+
 	sdpChar := C.gmf_sprintf_sdp(this.avCtx)
 	defer C.free(unsafe.Pointer(sdpChar))
 
@@ -484,6 +1393,10 @@ func (this *FmtCtx) GetSDPString() (sdp string) {
 }
 
 func (this *FmtCtx) WriteSDPFile(filename string) error {
+	if this.avCtx == nil {
+		return ErrNilContext
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return errors.New(fmt.Sprintf("Error open file:%s,error message:%s", filename, err))
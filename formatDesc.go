@@ -0,0 +1,93 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavformat
+
+#include "libavformat/avformat.h"
+
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// Describes muxer or demuxer, Flags are AVFMT_* flags.
+type FormatDescriptor struct {
+	Name       string
+	LongName   string
+	Extensions string
+	MimeType   string
+	Flags      int
+}
+
+// Returns all registered muxers.
+func OutputFormats() []FormatDescriptor {
+	var ofmt *C.struct_AVOutputFormat
+
+	result := make([]FormatDescriptor, 0)
+
+	for {
+		if ofmt = C.av_oformat_next(ofmt); ofmt == nil {
+			break
+		}
+
+		result = append(result, FormatDescriptor{
+			Name:       C.GoString(ofmt.name),
+			LongName:   C.GoString(ofmt.long_name),
+			Extensions: C.GoString(ofmt.extensions),
+			MimeType:   C.GoString(ofmt.mime_type),
+			Flags:      int(ofmt.flags),
+		})
+	}
+
+	return result
+}
+
+// Returns all registered demuxers.
+func InputFormats() []FormatDescriptor {
+	var ifmt *C.struct_AVInputFormat
+
+	result := make([]FormatDescriptor, 0)
+
+	for {
+		if ifmt = C.av_iformat_next(ifmt); ifmt == nil {
+			break
+		}
+
+		result = append(result, FormatDescriptor{
+			Name:       C.GoString(ifmt.name),
+			LongName:   C.GoString(ifmt.long_name),
+			Extensions: C.GoString(ifmt.extensions),
+			MimeType:   C.GoString(ifmt.mime_type),
+			Flags:      int(ifmt.flags),
+		})
+	}
+
+	return result
+}
+
+// Returns names of protocols, which are supported for input or output.
+func Protocols() []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0)
+
+	for _, output := range []C.int{0, 1} {
+		var opaque unsafe.Pointer
+
+		for {
+			name := C.avio_enum_protocols(&opaque, output)
+			if name == nil {
+				break
+			}
+
+			if s := C.GoString(name); !seen[s] {
+				seen[s] = true
+				result = append(result, s)
+			}
+		}
+	}
+
+	return result
+}
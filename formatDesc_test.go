@@ -0,0 +1,44 @@
+package gmf
+
+import (
+	"log"
+	"testing"
+)
+
+func TestOutputFormats(t *testing.T) {
+	found := false
+
+	for _, f := range OutputFormats() {
+		if f.Name == "mp4" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("Expected mp4 muxer to be registered")
+	}
+}
+
+func TestInputFormats(t *testing.T) {
+	formats := InputFormats()
+
+	if len(formats) == 0 {
+		t.Fatal("No demuxers found. Expected any non zero value.")
+	}
+
+	log.Println(len(formats), "demuxers registered")
+}
+
+func TestProtocols(t *testing.T) {
+	found := false
+
+	for _, p := range Protocols() {
+		if p == "file" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("Expected file protocol to be registered")
+	}
+}
@@ -1,12 +1,16 @@
 package gmf
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -40,9 +44,90 @@ func TestCtxInput(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	if inputCtx.Duration() <= 0 {
+		t.Fatalf("Expected duration > 0, %v got\n", inputCtx.Duration())
+	}
+
+	if IsNoPts(inputCtx.StartTime()) {
+		t.Fatal("Expected known start time")
+	}
+
+	if inputCtx.StartTimeDuration() < 0 {
+		t.Fatalf("Expected start time >= 0, %v got\n", inputCtx.StartTimeDuration())
+	}
+
+	inputCtx.CloseInputAndRelease()
+}
+
+func TestCtxInputWithOptions(t *testing.T) {
+	opts := NewDict([]Pair{{"probesize", "1000000"}, {"not_existing_option", "1"}})
+	defer Release(opts)
+
+	inputCtx, err := NewInputCtxWithOptions(inputSampleFilename, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.Count() != 1 || opts.Get("not_existing_option") != "1" {
+		t.Fatalf("Expected only unconsumed option left, %v got\n", opts.Pairs())
+	}
+
 	inputCtx.CloseInputAndRelease()
 }
 
+func TestOpenInputWithOptions(t *testing.T) {
+	ctx := NewCtx()
+	defer ctx.CloseInputAndRelease()
+
+	opts := NewDict([]Pair{{"probesize", "1000000"}, {"unknown_option", "1"}})
+	defer Release(opts)
+
+	if err := ctx.OpenInputWithOptions(inputSampleFilename, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := ctx.InputFormatName(); !strings.Contains(name, "mp4") {
+		t.Fatalf("Expected mp4 demuxer, '%s' got\n", name)
+	}
+
+	if opts.Get("unknown_option") != "1" {
+		t.Fatal("Expected unconsumed option to be kept")
+	}
+}
+
+func TestInterruptCallback(t *testing.T) {
+	ctx := NewCtx()
+	ctx.SetInterruptCallback(func() bool { return true })
+
+	if err := ctx.OpenInput(inputSampleFilename); err == nil {
+		t.Fatal("Expected interrupted OpenInput")
+	}
+
+	ctx.CloseInputAndRelease()
+
+	ctx = NewCtx()
+	ctx.SetIOTimeout(time.Minute)
+
+	if err := ctx.OpenInput(inputSampleFilename); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx.CloseInputAndRelease()
+}
+
+func TestFindStreamInfoWithOptions(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	if err := inputCtx.FindStreamInfoWithOptions(32768, int64(AV_TIME_BASE)); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestCtxOutput(t *testing.T) {
 	cases := map[interface{}]error{
 		outputSampleFilename:                        nil,
@@ -95,6 +180,32 @@ func TestNewStream(t *testing.T) {
 	log.Println("Dummy stream is created")
 }
 
+func TestNewStreamWithCodec(t *testing.T) {
+	outputCtx, err := NewOutputCtx("test.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(outputCtx)
+
+	c := assert(FindEncoder(AV_CODEC_ID_MPEG4)).(*Codec)
+
+	ost, cc, err := outputCtx.NewStreamWithCodec(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(cc)
+
+	if ost.CodecCtx() != cc {
+		t.Fatal("Expected codec context to be attached to stream")
+	}
+
+	if cc.Flags()&CODEC_FLAG_GLOBAL_HEADER == 0 {
+		t.Fatal("Expected CODEC_FLAG_GLOBAL_HEADER to be set for mp4")
+	}
+
+	log.Println("Stream with codec context is created")
+}
+
 func TestWriteHeader(t *testing.T) {
 	outputCtx, err := NewOutputCtx(outputSampleFilename)
 	if err != nil {
@@ -121,96 +232,817 @@ func TestWriteHeader(t *testing.T) {
 	}
 }
 
-func TestPacketsIterator(t *testing.T) {
-	inputCtx, err := NewInputCtx(inputSampleFilename)
+func TestWriteHeaderGlobalHeaderCheck(t *testing.T) {
+	outputCtx, err := NewOutputCtx("tests-global-header.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(outputCtx)
+	defer os.Remove("tests-global-header.mp4")
+
+	c := assert(FindEncoder(AV_CODEC_ID_MPEG4)).(*Codec)
+	stream := outputCtx.NewStream(c)
+
+	cc := NewCodecCtx(c).SetTimeBase(AVR{1, 25}).SetDimension(320, 200).SetPixFmt(AV_PIX_FMT_YUV420P)
+	defer Release(cc)
+
+	if err := cc.Open(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stream.SetCodecCtx(cc)
+
+	if err := outputCtx.WriteHeader(); err == nil {
+		t.Fatal("Expected error for encoder without CODEC_FLAG_GLOBAL_HEADER")
+	} else {
+		log.Println("Expected error:", err)
+	}
+}
+
+func TestWriteTrailerTwice(t *testing.T) {
+	outputCtx, err := NewOutputCtx(outputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(outputSampleFilename)
+
+	c := assert(FindEncoder(AV_CODEC_ID_MPEG1VIDEO)).(*Codec)
+	stream := outputCtx.NewStream(c)
+	defer Release(stream)
+	cc := NewCodecCtx(c).SetTimeBase(AVR{1, 25}).SetDimension(10, 10).SetFlag(CODEC_FLAG_GLOBAL_HEADER)
+	defer Release(cc)
+	stream.SetCodecCtx(cc)
+
+	if err := outputCtx.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := outputCtx.WriteTrailer(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// writes trailer once again, should be no-op
+	outputCtx.CloseOutputAndRelease()
+
+	log.Println("Trailer has been written once")
+}
+
+func TestFmtCtxSetOpt(t *testing.T) {
+	outputCtx, err := NewOutputCtx(outputSampleFilename)
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer Release(outputCtx)
+
+	outputCtx.SetFlag(AVFMT_FLAG_FLUSH_PACKETS)
+
+	if err := outputCtx.SetOpt("movflags", "+faststart"); err != nil {
+		t.Fatal(err)
+	}
 
+	if err := outputCtx.SetOpt("not_existing_option", "1"); err == nil {
+		t.Fatal("Expected error for unknown option")
+	}
+}
+
+func TestFmtCtxWrite(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
 	defer inputCtx.CloseInputAndRelease()
 
+	ist := assert(inputCtx.GetStream(0)).(*Stream)
+
+	outputCtx, err := NewOutputCtx("tests-output.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("tests-output.ts")
+
+	ost, err := outputCtx.AddStreamWithCodecPar(ist.CodecPar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ost.avStream.codecpar.codec_tag = 0
+
+	if err := outputCtx.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+
+	cnt := 0
 	for packet := range inputCtx.GetNewPackets() {
-		if packet.Size() <= 0 {
-			t.Fatal("Expected size > 0")
-		} else {
-			log.Printf("One packet has been read. size: %v, pts: %v\n", packet.Size(), packet.Pts())
+		if packet.StreamIndex() == ist.Index() {
+			packet.SetStreamIndex(0).RescaleFromTo(ist, ost)
+
+			if err := outputCtx.Write(packet); err != nil {
+				t.Fatal(err)
+			}
+			cnt++
 		}
 		Release(packet)
-
-		break
 	}
+
+	outputCtx.CloseOutputAndRelease()
+
+	log.Println(cnt, "packets written without interleaving")
 }
 
-func TestGetNextPacket(t *testing.T) {
-	inputCtx, err := NewInputCtx(inputSampleFilename)
+func TestCopyStream(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
+
+	outputCtx := assert(NewOutputCtxWithFormatName("", "mpegts")).(*FmtCtx)
+	defer Release(outputCtx)
+
+	ost, err := outputCtx.CopyStream(ist)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	defer inputCtx.CloseInputAndRelease()
+	if ost.CodecPar().CodecId() != ist.CodecPar().CodecId() || ost.CodecPar().CodecTag() != 0 {
+		t.Fatalf("Expected codec %d with zero tag, %d with tag %d got\n", ist.CodecPar().CodecId(), ost.CodecPar().CodecId(), ost.CodecPar().CodecTag())
+	}
 
-	packet := inputCtx.GetNextPacket()
-	if packet.Size() <= 0 {
-		t.Fatal("Expected size > 0")
-	} else {
-		log.Printf("One packet has been read. size: %v, pts: %v\n", packet.Size(), packet.Pts())
+	if ost.Disposition() != ist.Disposition() {
+		t.Fatalf("Expected disposition %d, %d got\n", ist.Disposition(), ost.Disposition())
+	}
+
+	imd, omd := ist.Metadata(), ost.Metadata()
+	defer Release(imd)
+	defer Release(omd)
+
+	if imd.Get("language") != omd.Get("language") {
+		t.Fatalf("Expected language '%s', '%s' got\n", imd.Get("language"), omd.Get("language"))
 	}
-	Release(packet)
 }
 
-var section *io.SectionReader
+func TestOpenOutput(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
 
-func customReader() ([]byte, int) {
-	var file *os.File
-	var err error
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
 
-	if section == nil {
-		file, err = os.Open(inputSampleFilename)
-		if err != nil {
-			panic(err)
-		}
+	outputCtx, err := NewOutputCtxWithFormatName("", "mpegts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(outputCtx)
 
-		fi, err := file.Stat()
-		if err != nil {
-			panic(err)
+	if err := outputCtx.OpenOutput("file:tests-open-output.ts", nil); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("tests-open-output.ts")
+
+	ost, err := outputCtx.AddStreamWithCodecPar(ist.CodecPar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ost.avStream.codecpar.codec_tag = 0
+
+	if err := outputCtx.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+
+	for packet := inputCtx.GetNextPacket(); packet != nil; packet = inputCtx.GetNextPacket() {
+		if packet.StreamIndex() == ist.Index() {
+			packet.SetStreamIndex(0).RescaleFromTo(ist, ost)
+
+			if err := outputCtx.Write(packet); err != nil {
+				t.Fatal(err)
+			}
 		}
+		Release(packet)
+	}
 
-		section = io.NewSectionReader(file, 0, fi.Size())
+	if err := outputCtx.WriteTrailer(); err != nil {
+		t.Fatal(err)
 	}
 
-	b := make([]byte, IO_BUFFER_SIZE)
+	if err := outputCtx.CloseOutput(); err != nil {
+		t.Fatal(err)
+	}
 
-	n, err := section.Read(b)
+	if fi, err := os.Stat("tests-open-output.ts"); err != nil || fi.Size() == 0 {
+		t.Fatalf("Expected not empty output file, %v got\n", err)
+	}
+}
+
+func TestNewOutputCtxToWriter(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
+
+	var b bytes.Buffer
+
+	outputCtx, err := NewOutputCtxToWriter(&b, "mpegts")
 	if err != nil {
-		fmt.Println("section.Read():", err)
-		file.Close()
+		t.Fatal(err)
 	}
 
-	return b, n
+	ost, err := outputCtx.AddStreamWithCodecPar(ist.CodecPar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ost.avStream.codecpar.codec_tag = 0
+
+	if err := outputCtx.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+
+	for packet := inputCtx.GetNextPacket(); packet != nil; packet = inputCtx.GetNextPacket() {
+		if packet.StreamIndex() == ist.Index() {
+			packet.SetStreamIndex(0).RescaleFromTo(ist, ost)
+
+			if err := outputCtx.Write(packet); err != nil {
+				t.Fatal(err)
+			}
+		}
+		Release(packet)
+	}
+
+	outputCtx.CloseOutputAndRelease()
+
+	if b.Len() == 0 || b.Len()%188 != 0 || b.Bytes()[0] != 0x47 {
+		t.Fatalf("Expected mpegts data, %d bytes got\n", b.Len())
+	}
+
+	log.Printf("%d bytes have been written to buffer\n", b.Len())
 }
 
-func TestAVIOContext(t *testing.T) {
-	ictx := NewCtx()
+func remuxToMD5(t *testing.T) string {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
 
-	if err := ictx.SetInputFormat("mov"); err != nil {
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
+
+	var b bytes.Buffer
+
+	outputCtx := assert(NewOutputCtxToWriter(&b, "md5")).(*FmtCtx)
+
+	ost, err := outputCtx.CopyStream(ist)
+	if err != nil {
 		t.Fatal(err)
 	}
 
-	avioCtx, err := NewAVIOContext(ictx, &AVIOHandlers{ReadPacket: customReader})
-	defer Release(avioCtx)
+	if err := outputCtx.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+
+	for packet := inputCtx.GetNextPacket(); packet != nil; packet = inputCtx.GetNextPacket() {
+		if packet.StreamIndex() == ist.Index() {
+			packet.SetStreamIndex(ost.Index()).RescaleFromTo(ist, ost)
+
+			if err := outputCtx.WriteInterleaved(packet); err != nil {
+				t.Fatal(err)
+			}
+		}
+		Release(packet)
+	}
+
+	outputCtx.CloseOutputAndRelease()
+
+	hash, err := HashFromOutput(b.Bytes())
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	ictx.SetPb(avioCtx).OpenInput("")
+	return hash
+}
 
-	for p := range ictx.GetNewPackets() {
-		_ = p
-		Release(p)
+func TestMD5Muxer(t *testing.T) {
+	first, second := remuxToMD5(t), remuxToMD5(t)
+
+	if len(first) != 32 || first != second {
+		t.Fatalf("Expected the same md5 hashes, '%s' and '%s' got\n", first, second)
 	}
 
-	ictx.CloseInputAndRelease()
+	log.Println("MD5 of remuxed video:", first)
+}
+
+func TestNewOutputCtxToWriterNotSeekable(t *testing.T) {
+	var b bytes.Buffer
 
+	outputCtx, err := NewOutputCtxToWriter(&b, "mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outputCtx.CloseOutputAndRelease()
+
+	c := assert(FindEncoder(AV_CODEC_ID_MPEG4)).(*Codec)
+
+	_, cc, err := outputCtx.NewStreamWithCodec(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(cc)
+
+	cc.SetTimeBase(AVR{1, 25}).SetDimension(320, 200).SetPixFmt(AV_PIX_FMT_YUV420P)
+
+	if err := cc.Open(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := outputCtx.WriteHeader(); err == nil {
+		t.Fatal("Expected error for mp4 written to non-seekable output")
+	} else {
+		log.Println("Expected error:", err)
+	}
+}
+
+func TestGetBestStreamWithDecoder(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	ist, cc, err := inputCtx.GetBestStreamWithDecoder(AVMEDIA_TYPE_VIDEO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(cc)
+
+	if !cc.IsOpen() {
+		t.Fatal("Expected opened decoder")
+	}
+
+	if cc.Width() != inputSampleWidth || cc.Height() != inputSampleHeight {
+		t.Fatalf("Expected dimension = %dx%d, %dx%d got\n", inputSampleWidth, inputSampleHeight, cc.Width(), cc.Height())
+	}
+
+	_, again, err := inputCtx.GetBestStreamWithDecoder(AVMEDIA_TYPE_VIDEO)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again != cc {
+		t.Fatal("Expected the same decoder for the same stream")
+	}
+
+	// stream keeps its own reference, so decoder is still usable
+	Release(again)
+
+	if !cc.IsOpen() || !ist.CodecCtx().IsOpen() {
+		t.Fatal("Expected decoder to be opened after caller released it")
+	}
+
+	log.Printf("Stream #%d decoder: %s\n", ist.Index(), cc.Codec().Name())
+}
+
+func TestGetBestStreamWithDecoderRelease(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+
+	_, cc, err := inputCtx.GetBestStreamWithDecoder(AVMEDIA_TYPE_VIDEO)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputCtx.CloseInputAndRelease()
+
+	if !cc.IsOpen() {
+		t.Fatal("Expected decoder to be opened until caller releases it")
+	}
+
+	Release(cc)
+
+	if cc.IsOpen() {
+		t.Fatal("Expected decoder to be freed after the last release")
+	}
+}
+
+func TestBitRate(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	if inputCtx.BitRate() <= 0 {
+		t.Fatalf("Expected positive bit rate, %d got\n", inputCtx.BitRate())
+	}
+
+	ist, err := inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ist.BitRate() <= 0 {
+		t.Fatalf("Expected positive stream bit rate, %d got\n", ist.BitRate())
+	}
+
+	log.Printf("Bit rate: %d, video stream: %d\n", inputCtx.BitRate(), ist.BitRate())
+}
+
+func TestStreamIndex(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	streams := inputCtx.StreamsOfType(AVMEDIA_TYPE_VIDEO)
+	if len(streams) == 0 {
+		t.Fatal("Expected video streams")
+	}
+
+	idx, err := inputCtx.StreamIndex(AVMEDIA_TYPE_VIDEO, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if idx != streams[0].Index() {
+		t.Fatalf("Expected index %d, %d got\n", streams[0].Index(), idx)
+	}
+
+	if _, err := inputCtx.StreamIndex(AVMEDIA_TYPE_VIDEO, len(streams)); err == nil {
+		t.Fatal("Expected error for missing stream")
+	}
+}
+
+func TestGuessFrameRate(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	ist, err := inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := inputCtx.GuessFrameRate(ist, nil); r.Num <= 0 || r.Den <= 0 {
+		t.Fatalf("Expected positive frame rate, %v got\n", r)
+	}
+
+	log.Printf("Guessed frame rate: %v\n", inputCtx.GuessFrameRate(ist, nil))
+}
+
+func TestMetadata(t *testing.T) {
+	outputCtx, err := NewOutputCtx(outputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(outputCtx)
+
+	d := NewDict([]Pair{{"title", "gmf test"}, {"artist", "gmf"}})
+	defer Release(d)
+
+	outputCtx.SetMetadata(d)
+
+	md := outputCtx.Metadata()
+	defer Release(md)
+
+	if md.Count() != 2 {
+		t.Fatalf("Expected 2 entries, %d got\n", md.Count())
+	}
+
+	if md.Get("title") != "gmf test" {
+		t.Fatalf("Expected title 'gmf test', '%s' got\n", md.Get("title"))
+	}
+}
+
+func TestPacketsIterator(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	for packet := range inputCtx.GetNewPackets() {
+		if packet.Size() <= 0 {
+			t.Fatal("Expected size > 0")
+		} else {
+			log.Printf("One packet has been read. size: %v, pts: %v\n", packet.Size(), packet.Pts())
+		}
+		Release(packet)
+
+		break
+	}
+}
+
+func TestPacketsIteratorErr(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	for packet := range inputCtx.GetNewPackets() {
+		Release(packet)
+	}
+
+	if err := inputCtx.Err(); err != nil {
+		t.Fatalf("Expected nil error at the end of input, '%v' got\n", err)
+	}
+}
+
+func TestPacketsIteratorWithContext(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	c, cancel := context.WithCancel(context.Background())
+
+	cnt := 0
+	for packet := range inputCtx.GetNewPacketsWithContext(c) {
+		Release(packet)
+
+		if cnt++; cnt == 5 {
+			cancel()
+		}
+	}
+
+	if inputCtx.Err() != context.Canceled {
+		t.Fatalf("Expected context.Canceled, '%v' got\n", inputCtx.Err())
+	}
+
+	// one packet could be already read, when context is cancelled
+	if cnt < 5 || cnt > 6 {
+		t.Fatalf("Expected 5 packets before cancellation, %d got\n", cnt)
+	}
+
+	// cancelled context shouldn't abort reading after the channel is closed
+	packet := inputCtx.GetNextPacket()
+	if packet == nil {
+		t.Fatalf("Expected packet after cancellation, '%v' got\n", inputCtx.Err())
+	}
+	Release(packet)
+}
+
+func TestGetNextPacket(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	packet := inputCtx.GetNextPacket()
+	if packet.Size() <= 0 {
+		t.Fatal("Expected size > 0")
+	} else {
+		log.Printf("One packet has been read. size: %v, pts: %v\n", packet.Size(), packet.Pts())
+	}
+	Release(packet)
+}
+
+func TestReadPacket(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	packet := NewPacket()
+	defer Release(packet)
+
+	cnt := 0
+	for {
+		err := inputCtx.ReadPacket(packet)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cnt++
+	}
+
+	if cnt == 0 {
+		t.Fatal("Expected packets > 0")
+	}
+
+	log.Println(cnt, "packets have been read")
+}
+
+func TestOnProgress(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	var calls int
+	var lastPts, lastPos int64
+
+	inputCtx.OnProgress(func(pts int64, pos int64) {
+		calls++
+		if !IsNoPts(pts) {
+			lastPts = pts
+		}
+		lastPos = pos
+	})
+
+	cnt := 0
+	for packet := inputCtx.GetNextPacket(); packet != nil; packet = inputCtx.GetNextPacket() {
+		cnt++
+		Release(packet)
+	}
+
+	if calls != cnt {
+		t.Fatalf("Expected %d progress calls, %d got\n", cnt, calls)
+	}
+
+	if lastPts <= 0 || lastPos <= 0 {
+		t.Fatalf("Expected positive pts and pos, %d and %d got\n", lastPts, lastPos)
+	}
+
+	log.Printf("Progress: %d of %d\n", lastPts, int64(inputCtx.Duration()/time.Microsecond))
+}
+
+func TestOnProgressWithContext(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	var calls int
+	inputCtx.OnProgress(func(pts int64, pos int64) {
+		calls++
+	})
+
+	cnt := 0
+	for packet := range inputCtx.GetNewPacketsWithContext(context.Background()) {
+		cnt++
+		Release(packet)
+	}
+
+	if cnt == 0 || calls != cnt {
+		t.Fatalf("Expected %d progress calls, %d got\n", cnt, calls)
+	}
+}
+
+func TestReadPause(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	// local files don't support pausing
+	if err := inputCtx.ReadPause(); err == nil {
+		t.Fatal("Expected error for pausing of local file")
+	} else {
+		log.Println("Expected error:", err)
+	}
+
+	if err := inputCtx.ReadPlay(); err == nil {
+		t.Fatal("Expected error for playing of local file")
+	}
+}
+
+func TestSeekFrameAt(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
+
+	if err := inputCtx.SeekFrameAt(time.Second, ist.Index()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := inputCtx.SeekFrameAt(time.Hour, ist.Index()); err != ErrSeekBeyondEOF {
+		t.Fatalf("Expected ErrSeekBeyondEOF, '%v' got\n", err)
+	}
+
+	packet := inputCtx.GetNextPacket()
+	if packet == nil {
+		t.Fatal("Expected packet after seeking")
+	}
+
+	log.Printf("Packet after seeking. pts: %v\n", packet.Pts())
+	Release(packet)
+}
+
+func TestSeekFile(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	ts := int64(AV_TIME_BASE)
+
+	if err := inputCtx.SeekFile(-1, 0, ts, ts, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := inputCtx.SeekFile(-1, ts, 0, ts, 0); err == nil {
+		t.Fatal("Expected error for invalid range")
+	}
+}
+
+var section *io.SectionReader
+
+func customReader() ([]byte, int) {
+	var file *os.File
+	var err error
+
+	if section == nil {
+		file, err = os.Open(inputSampleFilename)
+		if err != nil {
+			panic(err)
+		}
+
+		fi, err := file.Stat()
+		if err != nil {
+			panic(err)
+		}
+
+		section = io.NewSectionReader(file, 0, fi.Size())
+	}
+
+	b := make([]byte, IO_BUFFER_SIZE)
+
+	n, err := section.Read(b)
+	if err != nil {
+		fmt.Println("section.Read():", err)
+		file.Close()
+	}
+
+	return b, n
+}
+
+func TestAVIOContext(t *testing.T) {
+	ictx := NewCtx()
+
+	if err := ictx.SetInputFormat("mov"); err != nil {
+		t.Fatal(err)
+	}
+
+	avioCtx, err := NewAVIOContext(ictx, &AVIOHandlers{ReadPacket: customReader})
+	defer Release(avioCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ictx.SetPb(avioCtx).OpenInput("")
+
+	for p := range ictx.GetNewPackets() {
+		_ = p
+		Release(p)
+	}
+
+	ictx.CloseInputAndRelease()
+
+}
+
+func TestNewInputCtxFromReader(t *testing.T) {
+	file, err := os.Open(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	ictx, err := NewInputCtxFromReader(file, "mov")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ictx.CloseInputAndRelease()
+
+	cnt := 0
+	for p := range ictx.GetNewPackets() {
+		cnt++
+		Release(p)
+	}
+
+	if cnt == 0 {
+		t.Fatal("Expected packets > 0")
+	}
+
+	log.Println(cnt, "packets have been read from io.Reader")
+}
+
+func TestSetInputFormatUnknown(t *testing.T) {
+	ctx := NewCtx()
+	defer Release(ctx)
+
+	if err := ctx.SetInputFormat("xyz"); err == nil {
+		t.Fatal("Expected error for unknown input format")
+	} else {
+		log.Println("Expected error:", err)
+	}
 }
 
 func ExampleNewAVIOContext(t *testing.T) {
@@ -4,9 +4,13 @@ package gmf
 
 #cgo pkg-config: libavcodec libavutil
 
+#include <string.h>
+
 #include "libavcodec/avcodec.h"
 #include "libavutil/frame.h"
 #include "libavutil/imgutils.h"
+#include "libavutil/pixdesc.h"
+#include "libavutil/samplefmt.h"
 
 void gmf_set_frame_data(AVFrame *frame, int idx, int l_size, uint8_t data) {
     if(!frame) {
@@ -20,15 +24,62 @@ int gmf_get_frame_line_size(AVFrame *frame, int idx) {
 	return frame->linesize[idx];
 }
 
+int gmf_frame_plane_size(AVFrame *frame, int idx) {
+	const AVPixFmtDescriptor *desc;
+	int h = frame->height;
+
+	if (idx < 0 || idx >= AV_NUM_DATA_POINTERS || !frame->data[idx]) {
+		return 0;
+	}
+
+	// audio, all planes have the same size
+	if (frame->nb_samples > 0) {
+		return frame->linesize[0];
+	}
+
+	if (frame->linesize[idx] <= 0 || !(desc = av_pix_fmt_desc_get(frame->format))) {
+		return 0;
+	}
+
+	if (desc->flags & AV_PIX_FMT_FLAG_PAL && idx == 1) {
+		return AVPALETTE_SIZE;
+	}
+
+	if (idx == 1 || idx == 2) {
+		h = -((-h) >> desc->log2_chroma_h);
+	}
+
+	return frame->linesize[idx] * h;
+}
+
+uint8_t *gmf_frame_channel_data(AVFrame *frame, int ch) {
+	return frame->extended_data[ch];
+}
+
+void gmf_copy_frame_line(AVFrame *frame, int idx, int line, uint8_t *src, int len) {
+	memcpy(frame->data[idx] + line * frame->linesize[idx], src, len);
+}
+
 */
 import "C"
 
 import (
 	"errors"
 	"fmt"
+	"image"
+	"time"
 	"unsafe"
 )
 
+// Returned by ToImage, if frame pixel format can't be mapped to image.Image.
+type PixFmtError struct {
+	PixFmt int32
+}
+
+func (this *PixFmtError) Error() string {
+	return fmt.Sprintf("unsupported pixel format %d, convert frame to RGBA, RGB24 or GRAY8 with SwsCtx first", this.PixFmt)
+}
+
 type Frame struct {
 	avFrame   *C.struct_AVFrame
 	mediaType int32
@@ -36,7 +87,10 @@ type Frame struct {
 }
 
 func NewFrame() *Frame {
-	return &Frame{avFrame: C.av_frame_alloc()}
+	f := &Frame{avFrame: C.av_frame_alloc()}
+	setAutoRelease(f)
+
+	return f
 }
 
 func (this *Frame) EncodeNewPacket(cc *CodecCtx) (*Packet, bool, error) {
@@ -83,8 +137,19 @@ func (this *Frame) Unref() {
 	C.av_frame_unref(this.avFrame)
 }
 
-func (this *Frame) SetPts(val int64) {
+// Returns pts in seconds, s is the stream frame is decoded from or encoded to. Unset pts is converted to 0.
+func (this *Frame) PtsSeconds(s *Stream) float64 {
+	return this.PtsTime(s).Seconds()
+}
+
+// Same as PtsSeconds, but returns time.Duration.
+func (this *Frame) PtsTime(s *Stream) time.Duration {
+	return toDuration(this.Pts(), s.TimeBase())
+}
+
+func (this *Frame) SetPts(val int64) *Frame {
 	this.avFrame.pts = (_Ctype_int64_t)(val)
+	return this
 }
 
 func (this *Frame) SetBestPts() {
@@ -92,8 +157,8 @@ func (this *Frame) SetBestPts() {
 }
 
 // AVPixelFormat for video frames, AVSampleFormat for audio
-func (this *Frame) Format() int {
-	return int(this.avFrame.format)
+func (this *Frame) Format() int32 {
+	return int32(this.avFrame.format)
 }
 
 func (this *Frame) Width() int {
@@ -112,12 +177,19 @@ func (this *Frame) SetPktPts(val int64) {
 	this.avFrame.pkt_pts = (_Ctype_int64_t)(val)
 }
 
-func (this *Frame) PktDts() int {
-	return int(this.avFrame.pkt_dts)
+func (this *Frame) PktDts() int64 {
+	return int64(this.avFrame.pkt_dts)
 }
 
-func (this *Frame) SetPktDts(val int) {
+func (this *Frame) SetPktDts(val int64) *Frame {
 	this.avFrame.pkt_dts = (_Ctype_int64_t)(val)
+	return this
+}
+
+// Returns frame timestamp estimated by decoder, it's usually the best choice for pts
+// of decoded frames. AV_NOPTS_VALUE is returned, if it's unknown.
+func (this *Frame) BestEffortTimestamp() int64 {
+	return int64(C.av_frame_get_best_effort_timestamp(this.avFrame))
 }
 
 func (this *Frame) TimeStamp() int {
@@ -140,6 +212,19 @@ func (this *Frame) NbSamples() int {
 	return int(this.avFrame.nb_samples)
 }
 
+func (this *Frame) SampleRate() int {
+	return int(this.avFrame.sample_rate)
+}
+
+func (this *Frame) SetSampleRate(val int) *Frame {
+	this.avFrame.sample_rate = C.int(val)
+	return this
+}
+
+func (this *Frame) ChannelLayout() int64 {
+	return int64(this.avFrame.channel_layout)
+}
+
 func (this *Frame) Channels() int {
 	return int(this.avFrame.channels)
 }
@@ -163,19 +248,38 @@ func (this *Frame) ImgAlloc() error {
 	if ret := int(C.av_image_alloc(
 		(**C.uint8_t)(unsafe.Pointer(&this.avFrame.data)),
 		(*_Ctype_int)(unsafe.Pointer(&this.avFrame.linesize)),
-		C.int(this.Width()), C.int(this.Height()), int32(this.Format()), 32)); ret < 0 {
+		C.int(this.Width()), C.int(this.Height()), this.Format(), 32)); ret < 0 {
 		return errors.New(fmt.Sprintf("Unable to allocate raw image buffer: %v", AvError(ret)))
 	}
 
 	return nil
 }
 
+// Allocates reference counted data buffers for frame with format and dimensions
+// (or nb_samples and channel layout for audio) set. Align 0 picks suitable one for current CPU.
+func (this *Frame) AllocBuffer(align int) error {
+	if ret := int(C.av_frame_get_buffer(this.avFrame, C.int(align))); ret < 0 {
+		return errors.New(fmt.Sprintf("Unable to allocate frame buffer: %s", AvError(ret)))
+	}
+
+	return nil
+}
+
+// Ensures that frame data is writable, copying it if buffers are shared with other frames.
+func (this *Frame) MakeWritable() error {
+	if ret := int(C.av_frame_make_writable(this.avFrame)); ret < 0 {
+		return errors.New(fmt.Sprintf("Unable to make frame writable: %s", AvError(ret)))
+	}
+
+	return nil
+}
+
 func NewAudioFrame(sampleFormat int32, channels, nb_samples int) (*Frame, error) {
 	this := NewFrame()
 	this.mediaType = AVMEDIA_TYPE_AUDIO
 	this.SetNbSamples(nb_samples)
 	this.SetFormat(sampleFormat)
-	this.SetChannelLayout(channels)
+	this.SetChannelLayout(int64(C.av_get_default_channel_layout(C.int(channels)))).SetChannels(channels)
 
 	//the codec gives us the frame size, in samples,
 	//we calculate the size of the samples buffer in bytes
@@ -203,12 +307,289 @@ func (this *Frame) SetData(idx int, lineSize int, data int) *Frame {
 	return this
 }
 
+// Returns size in bytes of one line of plane idx, it may be larger than the visible width.
 func (this *Frame) LineSize(idx int) int {
 	return int(C.gmf_get_frame_line_size(this.avFrame, C.int(idx)))
 }
 
+// Returns slice, which aliases data of plane idx, nil if there is no such plane.
+// Its length is LineSize(idx) multiplied by plane height. The slice is valid only while
+// the frame is alive and references the same buffer, i.e. until it's released, unreferenced
+// or made writable. Call MakeWritable before modifying data of shared frame.
+func (this *Frame) Data(idx int) []byte {
+	size := int(C.gmf_frame_plane_size(this.avFrame, C.int(idx)))
+	if size <= 0 {
+		return nil
+	}
+
+	return (*[1 << 30]byte)(unsafe.Pointer(this.avFrame.data[idx]))[:size:size]
+}
+
+// Returns samples of audio channel as a slice of frame buffer, valid on the same terms as Data.
+// Planar formats have separate buffer per channel, for packed ones samples of all channels
+// are interleaved in the buffer of channel 0 (L R L R ... for stereo), other channels return nil.
+func (this *Frame) SampleData(channel int) []byte {
+	if this.avFrame.extended_data == nil || this.NbSamples() <= 0 || channel < 0 || channel >= this.Channels() {
+		return nil
+	}
+
+	bps := int(C.av_get_bytes_per_sample(this.Format()))
+	if bps <= 0 {
+		return nil
+	}
+
+	size := this.NbSamples() * bps
+
+	if C.av_sample_fmt_is_planar(this.Format()) == 0 {
+		if channel > 0 {
+			return nil
+		}
+		size *= this.Channels()
+	}
+
+	data := C.gmf_frame_channel_data(this.avFrame, C.int(channel))
+	if data == nil {
+		return nil
+	}
+
+	return (*[1 << 30]byte)(unsafe.Pointer(data))[:size:size]
+}
+
+// Returns copy of samples of S16 or S16P frame, indexed by channel, then by sample,
+// packed samples are deinterleaved. Returns nil for other sample formats.
+func (this *Frame) Samples16() [][]int16 {
+	if this.Format() != AV_SAMPLE_FMT_S16 && this.Format() != AV_SAMPLE_FMT_S16P {
+		return nil
+	}
+
+	channels, n := this.Channels(), this.NbSamples()
+	if this.SampleData(0) == nil {
+		return nil
+	}
+
+	result := make([][]int16, channels)
+
+	for ch := range result {
+		result[ch] = make([]int16, n)
+
+		if this.Format() == AV_SAMPLE_FMT_S16P {
+			data := this.SampleData(ch)
+			copy(result[ch], (*[1 << 29]int16)(unsafe.Pointer(&data[0]))[:n:n])
+			continue
+		}
+
+		data := this.SampleData(0)
+		src := (*[1 << 29]int16)(unsafe.Pointer(&data[0]))[: n*channels : n*channels]
+		for i := range result[ch] {
+			result[ch][i] = src[i*channels+ch]
+		}
+	}
+
+	return result
+}
+
+// Same as Samples16, but for FLT and FLTP frames.
+func (this *Frame) SamplesFloat() [][]float32 {
+	if this.Format() != AV_SAMPLE_FMT_FLT && this.Format() != AV_SAMPLE_FMT_FLTP {
+		return nil
+	}
+
+	channels, n := this.Channels(), this.NbSamples()
+	if this.SampleData(0) == nil {
+		return nil
+	}
+
+	result := make([][]float32, channels)
+
+	for ch := range result {
+		result[ch] = make([]float32, n)
+
+		if this.Format() == AV_SAMPLE_FMT_FLTP {
+			data := this.SampleData(ch)
+			copy(result[ch], (*[1 << 28]float32)(unsafe.Pointer(&data[0]))[:n:n])
+			continue
+		}
+
+		data := this.SampleData(0)
+		src := (*[1 << 28]float32)(unsafe.Pointer(&data[0]))[: n*channels : n*channels]
+		for i := range result[ch] {
+			result[ch][i] = src[i*channels+ch]
+		}
+	}
+
+	return result
+}
+
+// Copies video frame data into image.Image.
+// RGBA frame produces *image.RGBA, RGB24 - *image.NRGBA, GRAY8 - *image.Gray.
+// For other pixel formats *PixFmtError is returned.
+func (this *Frame) ToImage() (image.Image, error) {
+	w, h := this.Width(), this.Height()
+	rect := image.Rect(0, 0, w, h)
+
+	if this.avFrame.data[0] == nil || w <= 0 || h <= 0 {
+		return nil, errors.New("frame has no image data")
+	}
+
+	lineSize := this.LineSize(0)
+	src := C.GoBytes(unsafe.Pointer(this.avFrame.data[0]), C.int(lineSize*h))
+
+	switch this.Format() {
+	case AV_PIX_FMT_RGBA:
+		img := image.NewRGBA(rect)
+		for y := 0; y < h; y++ {
+			copy(img.Pix[y*img.Stride:y*img.Stride+w*4], src[y*lineSize:y*lineSize+w*4])
+		}
+		return img, nil
+
+	case AV_PIX_FMT_RGB24:
+		img := image.NewNRGBA(rect)
+		for y := 0; y < h; y++ {
+			row := src[y*lineSize : y*lineSize+w*3]
+			dst := img.Pix[y*img.Stride : y*img.Stride+w*4]
+			for x := 0; x < w; x++ {
+				dst[x*4] = row[x*3]
+				dst[x*4+1] = row[x*3+1]
+				dst[x*4+2] = row[x*3+2]
+				dst[x*4+3] = 0xff
+			}
+		}
+		return img, nil
+
+	case AV_PIX_FMT_GRAY8:
+		img := image.NewGray(rect)
+		for y := 0; y < h; y++ {
+			copy(img.Pix[y*img.Stride:y*img.Stride+w], src[y*lineSize:y*lineSize+w])
+		}
+		return img, nil
+	}
+
+	return nil, &PixFmtError{PixFmt: this.Format()}
+}
+
+// Creates video frame from image.Image, copying pixel data.
+// *image.RGBA produces RGBA frame, *image.Gray - GRAY8,
+// *image.YCbCr - YUV420P, YUV422P or YUV444P depending on subsampling ratio.
+func NewFrameFromImage(img image.Image) (*Frame, error) {
+	var pixFmt int32
+
+	switch t := img.(type) {
+	case *image.RGBA:
+		pixFmt = AV_PIX_FMT_RGBA
+
+	case *image.Gray:
+		pixFmt = AV_PIX_FMT_GRAY8
+
+	case *image.YCbCr:
+		switch t.SubsampleRatio {
+		case image.YCbCrSubsampleRatio420:
+			pixFmt = AV_PIX_FMT_YUV420P
+		case image.YCbCrSubsampleRatio422:
+			pixFmt = AV_PIX_FMT_YUV422P
+		case image.YCbCrSubsampleRatio444:
+			pixFmt = AV_PIX_FMT_YUV444P
+		default:
+			return nil, errors.New(fmt.Sprintf("unsupported YCbCr subsample ratio: %v", t.SubsampleRatio))
+		}
+
+	default:
+		return nil, errors.New(fmt.Sprintf("unsupported image type %T", img))
+	}
+
+	r := img.Bounds()
+	w, h := r.Dx(), r.Dy()
+
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("image is empty")
+	}
+
+	this := NewFrame().SetWidth(w).SetHeight(h).SetFormat(pixFmt)
+	this.mediaType = AVMEDIA_TYPE_VIDEO
+
+	if err := this.ImgAlloc(); err != nil {
+		Release(this)
+		return nil, err
+	}
+
+	switch t := img.(type) {
+	case *image.RGBA:
+		for y := 0; y < h; y++ {
+			this.copyLine(0, y, t.Pix[t.PixOffset(r.Min.X, r.Min.Y+y):], w*4)
+		}
+
+	case *image.Gray:
+		for y := 0; y < h; y++ {
+			this.copyLine(0, y, t.Pix[t.PixOffset(r.Min.X, r.Min.Y+y):], w)
+		}
+
+	case *image.YCbCr:
+		for y := 0; y < h; y++ {
+			this.copyLine(0, y, t.Y[t.YOffset(r.Min.X, r.Min.Y+y):], w)
+		}
+
+		cw, ch, vsub := w, h, 1
+
+		switch t.SubsampleRatio {
+		case image.YCbCrSubsampleRatio420:
+			cw, ch, vsub = (w+1)/2, (h+1)/2, 2
+		case image.YCbCrSubsampleRatio422:
+			cw = (w + 1) / 2
+		}
+
+		for y := 0; y < ch; y++ {
+			offset := t.COffset(r.Min.X, r.Min.Y+y*vsub)
+			this.copyLine(1, y, t.Cb[offset:], cw)
+			this.copyLine(2, y, t.Cr[offset:], cw)
+		}
+	}
+
+	return this, nil
+}
+
+func (this *Frame) copyLine(idx, line int, src []byte, length int) {
+	C.gmf_copy_frame_line(this.avFrame, C.int(idx), C.int(line), (*C.uint8_t)(unsafe.Pointer(&src[0])), C.int(length))
+}
+
+// Returns new frame, referencing the same data buffers.
+// Clone should be released independently.
+func (this *Frame) Clone() (*Frame, error) {
+	avFrame := C.av_frame_clone(this.avFrame)
+	if avFrame == nil {
+		return nil, errors.New("Unable to clone frame")
+	}
+
+	f := &Frame{avFrame: avFrame, mediaType: this.mediaType}
+	setAutoRelease(f)
+
+	return f, nil
+}
+
+// Copies pts, metadata, side data and other properties, but not data, from src.
+func (this *Frame) CopyProps(src *Frame) error {
+	if averr := C.av_frame_copy_props(this.avFrame, src.avFrame); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to copy frame properties: %s", AvError(int(averr))))
+	}
+
+	return nil
+}
+
+// Makes frame reference data of src without copying it.
+// Frame should be unreferenced before.
+func (this *Frame) Ref(src *Frame) error {
+	if ret := int(C.av_frame_ref(this.avFrame, src.avFrame)); ret < 0 {
+		return errors.New(fmt.Sprintf("Unable to reference frame: %s", AvError(ret)))
+	}
+
+	this.mediaType = src.mediaType
+
+	return nil
+}
+
 func (this *Frame) CloneNewFrame() *Frame {
-	return &Frame{avFrame: C.av_frame_clone(this.avFrame)}
+	f := &Frame{avFrame: C.av_frame_clone(this.avFrame)}
+	setAutoRelease(f)
+
+	return f
 }
 
 func (this *Frame) Free() {
@@ -220,7 +601,7 @@ func (this *Frame) SetNbSamples(val int) *Frame {
 	return this
 }
 
-func (this *Frame) SetChannelLayout(val int) *Frame {
+func (this *Frame) SetChannelLayout(val int64) *Frame {
 	this.avFrame.channel_layout = (_Ctype_uint64_t)(val)
 	return this
 }
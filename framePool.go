@@ -0,0 +1,57 @@
+package gmf
+
+// Pool of frames, which allows to reuse AVFrame structs instead of allocating
+// new ones for every decoded or encoded frame. Frames taken by Get should be
+// returned by Put instead of Release.
+type FramePool struct {
+	frames chan *Frame
+	CgoMemoryManage
+}
+
+// Creates pool, which keeps at most size free frames.
+func NewFramePool(size int) *FramePool {
+	return &FramePool{frames: make(chan *Frame, size)}
+}
+
+// Returns free frame from the pool or allocates new one if the pool is empty.
+func (this *FramePool) Get() *Frame {
+	select {
+	case f := <-this.frames:
+		return f
+	default:
+		return NewFrame()
+	}
+}
+
+// Unreferences frame buffers and returns it to the pool. Frame is freed, if the pool is full.
+// Note that buffers allocated by ImgAlloc aren't reference counted and aren't freed here.
+func (this *FramePool) Put(f *Frame) {
+	if f == nil || f.avFrame == nil {
+		return
+	}
+
+	f.Unref()
+	f.mediaType = 0
+
+	select {
+	case this.frames <- f:
+	default:
+		f.Free()
+	}
+}
+
+// Returns number of free frames in the pool.
+func (this *FramePool) Len() int {
+	return len(this.frames)
+}
+
+func (this *FramePool) Free() {
+	for {
+		select {
+		case f := <-this.frames:
+			f.Free()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package gmf
+
+import (
+	"log"
+	"testing"
+)
+
+func TestFramePool(t *testing.T) {
+	pool := NewFramePool(2)
+	defer Release(pool)
+
+	f := pool.Get()
+	f.SetWidth(10).SetHeight(10).SetFormat(AV_PIX_FMT_YUV420P)
+
+	pool.Put(f)
+
+	if pool.Len() != 1 {
+		t.Fatalf("Expected 1 frame in pool, %d got\n", pool.Len())
+	}
+
+	reused := pool.Get()
+	if reused != f {
+		t.Fatal("Expected frame to be reused")
+	}
+
+	if reused.Width() != 0 || reused.Height() != 0 {
+		t.Fatalf("Expected unreferenced frame, %dx%d got\n", reused.Width(), reused.Height())
+	}
+
+	pool.Put(reused)
+
+	log.Println("FramePool reuses frames")
+}
+
+func BenchmarkNewFrame(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		f := NewFrame()
+		f.SetWidth(320).SetHeight(200).SetFormat(AV_PIX_FMT_YUV420P)
+		Release(f)
+	}
+}
+
+func BenchmarkFramePool(b *testing.B) {
+	pool := NewFramePool(1)
+	defer Release(pool)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		f := pool.Get()
+		f.SetWidth(320).SetHeight(200).SetFormat(AV_PIX_FMT_YUV420P)
+		pool.Put(f)
+	}
+}
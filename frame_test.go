@@ -0,0 +1,279 @@
+package gmf
+
+import (
+	"encoding/binary"
+	"image"
+	"log"
+	"math"
+	"testing"
+)
+
+func TestFrameToImage(t *testing.T) {
+	w, h := 16, 8
+
+	frame := NewFrame().SetWidth(w).SetHeight(h).SetFormat(AV_PIX_FMT_GRAY8)
+	defer Release(frame)
+
+	if err := frame.ImgAlloc(); err != nil {
+		t.Fatal(err)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			frame.SetData(0, y*frame.LineSize(0)+x, x+y)
+		}
+	}
+
+	img, err := frame.ToImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gray, ok := img.(*image.Gray)
+	if !ok {
+		t.Fatalf("Expected *image.Gray, %T got\n", img)
+	}
+
+	if gray.Bounds().Dx() != w || gray.Bounds().Dy() != h {
+		t.Fatalf("Expected dimension = %dx%d, %dx%d got\n", w, h, gray.Bounds().Dx(), gray.Bounds().Dy())
+	}
+
+	if v := gray.GrayAt(3, 5).Y; v != 8 {
+		t.Fatalf("Expected pixel value = 8, %d got\n", v)
+	}
+
+	log.Println("Frame has been converted to image")
+}
+
+func TestNewFrameFromImage(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 16, 8))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i)
+	}
+
+	frame, err := NewFrameFromImage(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(frame)
+
+	if frame.Width() != 16 || frame.Height() != 8 || frame.Format() != AV_PIX_FMT_GRAY8 {
+		t.Fatalf("Unexpected frame %dx%d, format %d\n", frame.Width(), frame.Height(), frame.Format())
+	}
+
+	img, err := frame.ToImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := img.(*image.Gray).GrayAt(5, 2).Y; v != src.GrayAt(5, 2).Y {
+		t.Fatalf("Expected pixel value = %d, %d got\n", src.GrayAt(5, 2).Y, v)
+	}
+
+	ycbcr := image.NewYCbCr(image.Rect(0, 0, 16, 8), image.YCbCrSubsampleRatio420)
+
+	frame2, err := NewFrameFromImage(ycbcr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(frame2)
+
+	if frame2.Format() != AV_PIX_FMT_YUV420P {
+		t.Fatalf("Expected YUV420P format, %d got\n", frame2.Format())
+	}
+}
+
+func TestFrameToImageUnsupported(t *testing.T) {
+	frame := NewFrame().SetWidth(16).SetHeight(8).SetFormat(AV_PIX_FMT_YUV420P)
+	defer Release(frame)
+
+	if err := frame.ImgAlloc(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := frame.ToImage(); err == nil {
+		t.Fatal("Expected error for YUV420P frame")
+	} else if _, ok := err.(*PixFmtError); !ok {
+		t.Fatalf("Expected *PixFmtError, %T got\n", err)
+	}
+}
+
+func TestFrameClone(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 16, 8))
+
+	frame, err := NewFrameFromImage(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(frame)
+
+	clone, err := frame.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if clone.Width() != frame.Width() || clone.Height() != frame.Height() {
+		t.Fatalf("Expected dimension = %dx%d, %dx%d got\n", frame.Width(), frame.Height(), clone.Width(), clone.Height())
+	}
+
+	Release(clone)
+
+	ref := NewFrame()
+	defer Release(ref)
+
+	if err := ref.Ref(frame); err != nil {
+		t.Fatal(err)
+	}
+
+	ref.Unref()
+}
+
+func TestFrameTimestamps(t *testing.T) {
+	frame := NewFrame().SetPts(10).SetPktDts(9)
+	defer Release(frame)
+
+	if frame.Pts() != 10 || frame.PktDts() != 9 {
+		t.Fatalf("Expected pts 10 and pkt_dts 9, %d and %d got\n", frame.Pts(), frame.PktDts())
+	}
+
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
+
+	decoded := 0
+
+	for decoded == 0 {
+		packet := inputCtx.GetNextPacket()
+		if packet == nil {
+			t.Fatal("No frames decoded")
+		}
+
+		if packet.StreamIndex() != ist.Index() {
+			Release(packet)
+			continue
+		}
+
+		frames, err := ist.CodecCtx().Decode(packet)
+		Release(packet)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, f := range frames {
+			if IsNoPts(f.BestEffortTimestamp()) {
+				t.Fatal("Expected best effort timestamp of decoded frame")
+			}
+			Release(f)
+		}
+
+		decoded += len(frames)
+	}
+}
+
+func TestAudioFrameAccessors(t *testing.T) {
+	frame, err := NewAudioFrame(AV_SAMPLE_FMT_S16, 2, 1152)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(frame)
+
+	frame.SetSampleRate(44100)
+
+	if frame.Format() != AV_SAMPLE_FMT_S16 || frame.NbSamples() != 1152 || frame.SampleRate() != 44100 {
+		t.Fatalf("Unexpected format %d, nb_samples %d or sample rate %d\n", frame.Format(), frame.NbSamples(), frame.SampleRate())
+	}
+
+	if frame.Channels() != 2 || frame.ChannelLayout() == 0 {
+		t.Fatalf("Expected stereo frame, %d channels, layout %d got\n", frame.Channels(), frame.ChannelLayout())
+	}
+}
+
+func TestFrameAllocBuffer(t *testing.T) {
+	frame := NewFrame().SetWidth(320).SetHeight(200).SetFormat(AV_PIX_FMT_YUV420P)
+	defer Release(frame)
+
+	if err := frame.AllocBuffer(32); err != nil {
+		t.Fatal(err)
+	}
+
+	if frame.LineSize(0) < 320 {
+		t.Fatalf("Expected linesize >= 320, %d got\n", frame.LineSize(0))
+	}
+
+	clone, err := frame.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(clone)
+
+	if err := frame.MakeWritable(); err != nil {
+		t.Fatal(err)
+	}
+
+	if frame.avFrame.data[0] == clone.avFrame.data[0] {
+		t.Fatal("Expected shared buffer to be copied")
+	}
+}
+
+func TestFrameData(t *testing.T) {
+	frame := NewFrame().SetWidth(320).SetHeight(200).SetFormat(AV_PIX_FMT_YUV420P)
+	defer Release(frame)
+
+	if err := frame.AllocBuffer(32); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(frame.Data(0)) != frame.LineSize(0)*200 {
+		t.Fatalf("Expected luma plane size %d, %d got\n", frame.LineSize(0)*200, len(frame.Data(0)))
+	}
+
+	if len(frame.Data(1)) != frame.LineSize(1)*100 {
+		t.Fatalf("Expected chroma plane size %d, %d got\n", frame.LineSize(1)*100, len(frame.Data(1)))
+	}
+
+	if frame.Data(3) != nil {
+		t.Fatal("Expected no data for plane 3")
+	}
+
+	frame.Data(0)[0] = 42
+
+	if frame.Data(0)[0] != 42 {
+		t.Fatal("Expected data slice to alias frame buffer")
+	}
+}
+
+func TestFrameSamples(t *testing.T) {
+	frame := assert(NewAudioFrame(AV_SAMPLE_FMT_S16, 2, 4)).(*Frame)
+	defer Release(frame)
+
+	data := frame.SampleData(0)
+	if len(data) != 4*2*2 || frame.SampleData(1) != nil {
+		t.Fatalf("Expected interleaved 16 bytes in channel 0 only, %d got\n", len(data))
+	}
+
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint16(data[i*4:], uint16(int16(i)))
+		binary.LittleEndian.PutUint16(data[i*4+2:], uint16(int16(-i)))
+	}
+
+	samples := frame.Samples16()
+	if len(samples) != 2 || samples[0][3] != 3 || samples[1][3] != -3 {
+		t.Fatalf("Expected deinterleaved samples, %v got\n", samples)
+	}
+
+	planar := assert(NewAudioFrame(AV_SAMPLE_FMT_FLTP, 2, 4)).(*Frame)
+	defer Release(planar)
+
+	binary.LittleEndian.PutUint32(planar.SampleData(1)[4:], math.Float32bits(0.5))
+
+	floats := planar.SamplesFloat()
+	if len(floats) != 2 || floats[1][1] != 0.5 {
+		t.Fatalf("Expected 0.5 in the second channel, %v got\n", floats)
+	}
+
+	if planar.Samples16() != nil {
+		t.Fatal("Expected nil Samples16 for float frame")
+	}
+}
@@ -0,0 +1,86 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavcodec libavutil
+
+#include <stdlib.h>
+
+#include "libavcodec/avcodec.h"
+#include "libavutil/hwcontext.h"
+#include "libavutil/pixdesc.h"
+
+static enum AVPixelFormat gmf_get_hw_format(AVCodecContext *ctx, const enum AVPixelFormat *fmts) {
+	const enum AVPixelFormat *p;
+
+	for (p = fmts; *p != AV_PIX_FMT_NONE; p++) {
+		const AVPixFmtDescriptor *desc = av_pix_fmt_desc_get(*p);
+
+		if (desc && (desc->flags & AV_PIX_FMT_FLAG_HWACCEL)) {
+			return *p;
+		}
+	}
+
+	// no hardware format offered, fallback to software decoding
+	return avcodec_default_get_format(ctx, fmts);
+}
+
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// Creates hardware device context of deviceType (e.g. "cuda", "vaapi", "videotoolbox")
+// and attaches it to the codec context. Should be called before Open.
+// Decoded frames stay in device memory, use Frame.TransferToSoftware to get them back.
+func (this *CodecCtx) EnableHWAccel(deviceType string) error {
+	if this.IsOpen() {
+		return errors.New("hardware acceleration should be enabled before opening codec")
+	}
+
+	ctype := C.CString(deviceType)
+	defer C.free(unsafe.Pointer(ctype))
+
+	typ := C.av_hwdevice_find_type_by_name(ctype)
+	if typ == C.AV_HWDEVICE_TYPE_NONE {
+		return errors.New(fmt.Sprintf("unknown hardware device type '%s'", deviceType))
+	}
+
+	var deviceCtx *C.AVBufferRef
+
+	if averr := C.av_hwdevice_ctx_create(&deviceCtx, typ, nil, nil, 0); averr < 0 {
+		return errors.New(fmt.Sprintf("Unable to create '%s' device context: %s", deviceType, AvError(int(averr))))
+	}
+
+	// codec context owns the reference now
+	this.avCodecCtx.hw_device_ctx = deviceCtx
+	this.avCodecCtx.get_format = (*[0]byte)(C.gmf_get_hw_format)
+
+	return nil
+}
+
+// Copies data of hardware frame into new frame in host memory.
+func (this *Frame) TransferToSoftware() (*Frame, error) {
+	if this.avFrame.hw_frames_ctx == nil {
+		return nil, errors.New("frame is not a hardware frame")
+	}
+
+	result := NewFrame()
+	result.mediaType = this.mediaType
+
+	if averr := C.av_hwframe_transfer_data(result.avFrame, this.avFrame, 0); averr < 0 {
+		Release(result)
+		return nil, errors.New(fmt.Sprintf("Unable to transfer frame data: %s", AvError(int(averr))))
+	}
+
+	if averr := C.av_frame_copy_props(result.avFrame, this.avFrame); averr < 0 {
+		Release(result)
+		return nil, errors.New(fmt.Sprintf("Unable to copy frame properties: %s", AvError(int(averr))))
+	}
+
+	return result, nil
+}
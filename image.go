@@ -0,0 +1,200 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavcodec
+
+#include "libavcodec/avcodec.h"
+
+*/
+import "C"
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Decodes still image, e.g. JPEG or PNG file, into a frame in the decoder's native pixel format.
+// Returned frame should be released by caller.
+func DecodeImageFile(path string) (*Frame, error) {
+	ctx, err := NewInputCtx(path)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.CloseInputAndRelease()
+
+	return decodeFirstFrame(ctx)
+}
+
+// The same as DecodeImageFile, but image is read from data.
+func DecodeImageBytes(data []byte) (*Frame, error) {
+	ctx, err := NewInputCtxFromReader(bytes.NewReader(data), "")
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.CloseInputAndRelease()
+
+	return decodeFirstFrame(ctx)
+}
+
+func decodeFirstFrame(ctx *FmtCtx) (*Frame, error) {
+	ist, cc, err := ctx.GetBestStreamWithDecoder(AVMEDIA_TYPE_VIDEO)
+	if err != nil {
+		return nil, err
+	}
+	// releases only reference taken above, decoder is still cached by ist
+	defer Release(cc)
+
+	var frames []*Frame
+
+	for len(frames) == 0 {
+		p := ctx.GetNextPacket()
+		if p == nil {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			// flush decoder, if there are no more packets
+			frames, err = cc.Decode(nil)
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+
+			break
+		}
+
+		if p.StreamIndex() != ist.Index() {
+			Release(p)
+			continue
+		}
+
+		frames, err = cc.Decode(p)
+		Release(p)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(frames) == 0 {
+		return nil, errors.New("no frame decoded")
+	}
+
+	for _, f := range frames[1:] {
+		Release(f)
+	}
+
+	return frames[0], nil
+}
+
+// Encodes video frame into image, format is "jpeg" or "png". Frame is converted,
+// if encoder doesn't support its pixel format. Quality from 1 (worst) to 100 (best)
+// is used by JPEG encoder, 0 keeps encoder default.
+func EncodeImage(f *Frame, format string, quality int) ([]byte, error) {
+	var name string
+
+	switch format {
+	case "jpeg", "jpg", "mjpeg":
+		name = "mjpeg"
+	case "png":
+		name = "png"
+	default:
+		return nil, errors.New(fmt.Sprintf("unsupported image format '%s'", format))
+	}
+
+	codec, err := FindEncoder(name)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := f.Width(), f.Height()
+
+	pixFmt := f.Format()
+	if supported := codec.SupportedPixelFormats(); len(supported) > 0 && !hasPixFmt(supported, pixFmt) {
+		pixFmt = supported[0]
+	}
+
+	var src *Frame
+
+	if pixFmt != f.Format() {
+		sws := newSwsCtx(w, h, f.Format(), w, h, pixFmt, SWS_BICUBIC)
+		if sws == nil {
+			return nil, errors.New("unable to create scaling context")
+		}
+		defer Release(sws)
+
+		src = NewFrame().SetWidth(w).SetHeight(h).SetFormat(pixFmt)
+		if err := src.AllocBuffer(32); err != nil {
+			Release(src)
+			return nil, err
+		}
+
+		sws.Scale(f, src)
+	} else if src, err = f.Clone(); err != nil {
+		return nil, err
+	}
+	defer Release(src)
+
+	cc := NewCodecCtx(codec)
+	if cc == nil {
+		return nil, errors.New("unable to allocate codec context")
+	}
+	defer Release(cc)
+
+	cc.SetDimension(w, h).SetPixFmt(pixFmt).SetTimeBase(AVR{1, 25})
+
+	if name == "mjpeg" && quality > 0 {
+		if quality > 100 {
+			quality = 100
+		}
+
+		// maps quality to qscale, 2 is the best, 31 is the worst
+		lambda := (31 - (quality-1)*29/99) * FF_QP2LAMBDA
+
+		cc.SetFlag(AV_CODEC_FLAG_QSCALE)
+		cc.avCodecCtx.global_quality = C.int(lambda)
+		src.SetQuality(lambda)
+	}
+
+	if err := cc.Open(nil); err != nil {
+		return nil, err
+	}
+
+	src.SetPts(0)
+
+	packets, err := cc.Encode(src)
+	if err != nil {
+		return nil, err
+	}
+
+	flushed, err := cc.Encode(nil)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	packets = append(packets, flushed...)
+
+	defer func() {
+		for _, p := range packets {
+			Release(p)
+		}
+	}()
+
+	if len(packets) == 0 {
+		return nil, errors.New("no packet encoded")
+	}
+
+	return packets[0].Data(), nil
+}
+
+func hasPixFmt(list []int32, pixFmt int32) bool {
+	for _, f := range list {
+		if f == pixFmt {
+			return true
+		}
+	}
+
+	return false
+}
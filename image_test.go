@@ -0,0 +1,117 @@
+package gmf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
+
+func TestDecodeImageBytes(t *testing.T) {
+	frame, err := DecodeImageBytes(testPNG(t, 32, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(frame)
+
+	if frame.Width() != 32 || frame.Height() != 16 {
+		t.Fatalf("Expected 32x16 frame, %dx%d got\n", frame.Width(), frame.Height())
+	}
+}
+
+func TestDecodeImageFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "gmf-image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	f.Write(testPNG(t, 32, 16))
+	f.Close()
+
+	path := f.Name() + ".png"
+	if err := os.Rename(f.Name(), path); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(path)
+
+	frame, err := DecodeImageFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(frame)
+
+	if frame.Width() != 32 || frame.Height() != 16 {
+		t.Fatalf("Expected 32x16 frame, %dx%d got\n", frame.Width(), frame.Height())
+	}
+}
+
+func TestEncodeImage(t *testing.T) {
+	frame, err := DecodeImageBytes(testPNG(t, 32, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(frame)
+
+	for _, format := range []string{"jpeg", "png"} {
+		data, err := EncodeImage(frame, format, 90)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decoded, err := DecodeImageBytes(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.Width() != 32 || decoded.Height() != 16 {
+			t.Fatalf("Expected 32x16 %s, %dx%d got\n", format, decoded.Width(), decoded.Height())
+		}
+
+		Release(decoded)
+	}
+
+	if _, err := EncodeImage(frame, "bmp-unknown", 0); err == nil {
+		t.Fatal("Expected error for unsupported format")
+	}
+}
+
+func TestDecodeFirstFrameKeepsDecoder(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	frame, err := decodeFirstFrame(inputCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Release(frame)
+
+	ist, cc, err := inputCtx.GetBestStreamWithDecoder(AVMEDIA_TYPE_VIDEO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(cc)
+
+	if !cc.IsOpen() || !ist.CodecCtx().IsOpen() {
+		t.Fatal("Expected decoder of the context to stay opened")
+	}
+}
@@ -87,5 +87,10 @@ func (this *Image) Copy(frame *Frame) {
 }
 
 func (this *Image) Free() {
+	if this.avLineSize == nil {
+		return
+	}
+
 	C.free_ptr(this.avPointers, this.avLineSize)
+	this.avLineSize = nil
 }
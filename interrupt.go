@@ -0,0 +1,161 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavformat
+
+#include "libavformat/avformat.h"
+
+extern int interruptCallBack(void*);
+
+*/
+import "C"
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// Global map of interrupt callbacks, one per format context. Using ctx.avCtx pointer address as a key.
+// It's guarded by mutex, because callbacks are called from FFmpeg threads.
+var (
+	interruptMu  sync.RWMutex
+	interruptMap map[uintptr]func() bool = make(map[uintptr]func() bool)
+)
+
+// Installs callback, which is called by blocking I/O operations. Returning true aborts the operation.
+// Should be set before OpenInput to interrupt opening as well. Nil fn removes the callback.
+func (this *FmtCtx) SetInterruptCallback(fn func() bool) {
+	if this.avCtx == nil {
+		return
+	}
+
+	this.removeInterruptCallback()
+
+	if fn == nil {
+		this.avCtx.interrupt_callback.callback = nil
+		this.avCtx.interrupt_callback.opaque = nil
+		return
+	}
+
+	this.interruptKey = uintptr(unsafe.Pointer(this.avCtx))
+
+	interruptMu.Lock()
+	interruptMap[this.interruptKey] = fn
+	interruptMu.Unlock()
+
+	this.avCtx.interrupt_callback.callback = (*[0]byte)(C.interruptCallBack)
+	this.avCtx.interrupt_callback.opaque = unsafe.Pointer(this.avCtx)
+}
+
+// Aborts opening or reading, if it blocks longer than d.
+func (this *FmtCtx) SetIOTimeout(d time.Duration) {
+	this.touchIO()
+
+	this.SetInterruptCallback(func() bool {
+		return time.Since(time.Unix(0, atomic.LoadInt64(&this.ioStart))) > d
+	})
+}
+
+// Same as GetNewPackets, but reading is aborted and channel is closed, when c is cancelled.
+// Previously installed interrupt callback is still respected and it's restored, when channel is closed.
+func (this *FmtCtx) GetNewPacketsWithContext(c context.Context) <-chan *Packet {
+	prev := this.interruptCallback()
+
+	this.SetInterruptCallback(func() bool {
+		select {
+		case <-c.Done():
+			return true
+		default:
+		}
+
+		return prev != nil && prev()
+	})
+
+	yield := make(chan *Packet)
+
+	go func() {
+		defer close(yield)
+		defer this.SetInterruptCallback(prev)
+
+		this.err = nil
+
+		if this.avCtx == nil {
+			this.err = ErrNilContext
+			return
+		}
+
+		for {
+			p := NewPacket()
+
+			this.touchIO()
+
+			if ret := C.av_read_frame(this.avCtx, &p.avPacket); int(ret) < 0 {
+				if c.Err() != nil {
+					this.err = c.Err()
+				} else {
+					this.setReadError(int(ret))
+				}
+
+				Release(p)
+				return
+			}
+
+			this.progress(p)
+
+			select {
+			case yield <- p:
+			case <-c.Done():
+				this.err = c.Err()
+				Release(p)
+				return
+			}
+		}
+	}()
+
+	return yield
+}
+
+func (this *FmtCtx) interruptCallback() func() bool {
+	if this.interruptKey == 0 {
+		return nil
+	}
+
+	interruptMu.RLock()
+	defer interruptMu.RUnlock()
+
+	return interruptMap[this.interruptKey]
+}
+
+// Resets I/O timeout timer, it's called before each blocking operation.
+func (this *FmtCtx) touchIO() {
+	atomic.StoreInt64(&this.ioStart, time.Now().UnixNano())
+}
+
+func (this *FmtCtx) removeInterruptCallback() {
+	if this.interruptKey == 0 {
+		return
+	}
+
+	interruptMu.Lock()
+	delete(interruptMap, this.interruptKey)
+	interruptMu.Unlock()
+
+	this.interruptKey = 0
+}
+
+//export interruptCallBack
+func interruptCallBack(opaque unsafe.Pointer) C.int {
+	interruptMu.RLock()
+	fn, found := interruptMap[uintptr(opaque)]
+	interruptMu.RUnlock()
+
+	if found && fn() {
+		return 1
+	}
+
+	return 0
+}
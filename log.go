@@ -0,0 +1,77 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavutil
+
+#include <stdarg.h>
+#include "libavutil/log.h"
+
+extern void goLogCallBack(int, char*);
+
+#define GMF_LOG_LINE_SIZE 1024
+
+static void gmf_log_callback(void *avcl, int level, const char *fmt, va_list vl) {
+	char line[GMF_LOG_LINE_SIZE];
+	int print_prefix = 1;
+
+	if (level > av_log_get_level()) {
+		return;
+	}
+
+	// formats va_list and prepends "[codec @ 0x...]" prefix
+	av_log_format_line(avcl, level, fmt, vl, line, sizeof(line), &print_prefix);
+
+	goLogCallBack(level, line);
+}
+
+static void gmf_set_log_callback(int enable) {
+	av_log_set_callback(enable ? gmf_log_callback : av_log_default_callback);
+}
+
+*/
+import "C"
+
+import (
+	"sync"
+)
+
+var (
+	logMu       sync.RWMutex
+	logCallback func(level int, msg string)
+)
+
+// Sets global logging level, one of AV_LOG_* constants.
+func SetLogLevel(level int) {
+	C.av_log_set_level(C.int(level))
+}
+
+// Returns global logging level.
+func LogLevel() int {
+	return int(C.av_log_get_level())
+}
+
+// Routes library log messages to fn instead of stderr. Messages above the level set by
+// SetLogLevel are dropped. fn may be called concurrently from FFmpeg threads.
+// Nil fn restores default logging to stderr.
+func SetLogCallback(fn func(level int, msg string)) {
+	logMu.Lock()
+	logCallback = fn
+	logMu.Unlock()
+
+	if fn == nil {
+		C.gmf_set_log_callback(0)
+	} else {
+		C.gmf_set_log_callback(1)
+	}
+}
+
+func logMessage(level int, msg string) {
+	logMu.RLock()
+	fn := logCallback
+	logMu.RUnlock()
+
+	if fn != nil {
+		fn(level, msg)
+	}
+}
@@ -0,0 +1,40 @@
+package gmf
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSetLogCallback(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+
+	level := LogLevel()
+	defer SetLogLevel(level)
+
+	SetLogLevel(AV_LOG_INFO)
+	SetLogCallback(func(level int, msg string) {
+		mu.Lock()
+		lines = append(lines, msg)
+		mu.Unlock()
+	})
+	defer SetLogCallback(nil)
+
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	inputCtx.Dump()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !strings.Contains(strings.Join(lines, ""), "Input #0") {
+		t.Fatalf("Expected dump to be logged, %v got\n", lines)
+	}
+
+	log.Printf("%d lines have been logged\n", len(lines))
+}
@@ -0,0 +1,13 @@
+package gmf
+
+/*
+
+extern void goLogCallBack(int, char*);
+
+*/
+import "C"
+
+//export goLogCallBack
+func goLogCallBack(level C.int, msg *C.char) {
+	logMessage(int(level), C.GoString(msg))
+}
@@ -34,6 +34,9 @@ func (this *Option) Set(ctx interface{}) {
 	case int:
 		ret = int(C.av_opt_set_int(unsafe.Pointer(reflect.ValueOf(ctx).Pointer()), ckey, C.int64_t(this.Val.(int)), 0))
 
+	case int64:
+		ret = int(C.av_opt_set_int(unsafe.Pointer(reflect.ValueOf(ctx).Pointer()), ckey, C.int64_t(this.Val.(int64)), 0))
+
 	case SampleFmt:
 		ret = int(C.av_opt_set_sample_fmt(unsafe.Pointer(reflect.ValueOf(ctx).Pointer()), ckey, (int32)(this.Val.(SampleFmt)), 0))
 
@@ -54,6 +57,6 @@ func (this *Option) Set(ctx interface{}) {
 	}
 
 	if ret < 0 {
-		log.Printf("unable to set key '%s' value '%d', error: %s\n", this.Key, this.Val.(int), AvError(int(ret)))
+		log.Printf("unable to set key '%s' value '%v', error: %s\n", this.Key, this.Val, AvError(int(ret)))
 	}
 }
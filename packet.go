@@ -4,6 +4,8 @@ package gmf
 
 #cgo pkg-config: libavcodec
 
+#include <string.h>
+
 #include "libavcodec/avcodec.h"
 
 void shift_data(AVPacket *pkt, int offset) {
@@ -19,6 +21,8 @@ import "C"
 import (
 	"errors"
 	"fmt"
+	"io"
+	"time"
 	"unsafe"
 )
 
@@ -30,6 +34,22 @@ import (
 //
 var frames map[int32]*Frame = make(map[int32]*Frame, 0)
 
+var (
+	AV_PKT_FLAG_KEY     int = C.AV_PKT_FLAG_KEY
+	AV_PKT_FLAG_CORRUPT int = C.AV_PKT_FLAG_CORRUPT
+
+	AV_PKT_DATA_PALETTE                    int = C.AV_PKT_DATA_PALETTE
+	AV_PKT_DATA_NEW_EXTRADATA              int = C.AV_PKT_DATA_NEW_EXTRADATA
+	AV_PKT_DATA_PARAM_CHANGE               int = C.AV_PKT_DATA_PARAM_CHANGE
+	AV_PKT_DATA_REPLAYGAIN                 int = C.AV_PKT_DATA_REPLAYGAIN
+	AV_PKT_DATA_DISPLAYMATRIX              int = C.AV_PKT_DATA_DISPLAYMATRIX
+	AV_PKT_DATA_STEREO3D                   int = C.AV_PKT_DATA_STEREO3D
+	AV_PKT_DATA_CPB_PROPERTIES             int = C.AV_PKT_DATA_CPB_PROPERTIES
+	AV_PKT_DATA_A53_CC                     int = C.AV_PKT_DATA_A53_CC
+	AV_PKT_DATA_MASTERING_DISPLAY_METADATA int = C.AV_PKT_DATA_MASTERING_DISPLAY_METADATA
+	AV_PKT_DATA_CONTENT_LIGHT_LEVEL        int = C.AV_PKT_DATA_CONTENT_LIGHT_LEVEL
+)
+
 type Packet struct {
 	avPacket C.struct_AVPacket
 	CgoMemoryManage
@@ -43,9 +63,28 @@ func NewPacket() *Packet {
 	p.avPacket.data = nil
 	p.avPacket.size = 0
 
+	setAutoRelease(p)
+
 	return p
 }
 
+// Returns new reference counted packet with a copy of data, e.g. to feed decoder
+// with frames received from network. Timestamps should be set by SetPts and SetDts.
+func NewPacketFromBytes(data []byte) (*Packet, error) {
+	p := NewPacket()
+
+	if averr := C.av_new_packet(&p.avPacket, C.int(len(data))); averr < 0 {
+		Release(p)
+		return nil, errors.New(fmt.Sprintf("Unable to allocate packet: %s", AvError(int(averr))))
+	}
+
+	if len(data) > 0 {
+		C.memcpy(unsafe.Pointer(p.avPacket.data), unsafe.Pointer(&data[0]), C.size_t(len(data)))
+	}
+
+	return p, nil
+}
+
 // @todo should be private
 func (this *Packet) Decode(cc *CodecCtx) (*Frame, bool, int, error) {
 	var gotOutput int
@@ -81,6 +120,8 @@ func (this *Packet) Decode(cc *CodecCtx) (*Frame, bool, int, error) {
 
 func (this *Packet) DecodeToNewFrame(cc *CodecCtx) (*Frame, bool, int, error) {
 	f := &Frame{avFrame: C.av_frame_alloc(), mediaType: cc.Type()}
+	setAutoRelease(f)
+
 	return this.decode(cc, f)
 }
 
@@ -169,28 +210,57 @@ func (this *Packet) Pts() int64 {
 	return int64(this.avPacket.pts)
 }
 
-func (this *Packet) SetPts(pts int64) {
+// Returns pts in seconds, s is the stream packet belongs to. Unset pts is converted to 0.
+func (this *Packet) PtsSeconds(s *Stream) float64 {
+	return this.PtsTime(s).Seconds()
+}
+
+// Same as PtsSeconds, but returns time.Duration.
+func (this *Packet) PtsTime(s *Stream) time.Duration {
+	return toDuration(this.Pts(), s.TimeBase())
+}
+
+func (this *Packet) SetPts(pts int64) *Packet {
 	this.avPacket.pts = C.int64_t(pts)
+	return this
 }
 
 func (this *Packet) Dts() int64 {
 	return int64(this.avPacket.dts)
 }
 
-func (this *Packet) SetDts(val int64) {
+func (this *Packet) SetDts(val int64) *Packet {
 	this.avPacket.dts = _Ctype_int64_t(val)
+	return this
 }
 
+// Combination of AV_PKT_FLAG_* values.
 func (this *Packet) Flags() int {
 	return int(this.avPacket.flags)
 }
 
-func (this *Packet) Duration() int {
-	return int(this.avPacket.duration)
+func (this *Packet) SetFlags(val int) *Packet {
+	this.avPacket.flags = C.int(val)
+	return this
+}
+
+func (this *Packet) IsKeyPacket() bool {
+	return this.Flags()&AV_PKT_FLAG_KEY != 0
+}
+
+// Reports whether packet is flagged as corrupt by demuxer.
+func (this *Packet) IsCorrupt() bool {
+	return this.Flags()&AV_PKT_FLAG_CORRUPT != 0
+}
+
+// Duration in stream time base units, 0 if unknown.
+func (this *Packet) Duration() int64 {
+	return int64(this.avPacket.duration)
 }
 
-func (this *Packet) SetDuration(duration int) {
-	this.avPacket.duration = C.int(duration)
+func (this *Packet) SetDuration(duration int64) *Packet {
+	this.avPacket.duration = C.int64_t(duration)
+	return this
 }
 
 func (this *Packet) StreamIndex() int {
@@ -205,18 +275,75 @@ func (this *Packet) Pos() int64 {
 	return int64(this.avPacket.pos)
 }
 
+// Returns copy of packet payload.
 func (this *Packet) Data() []byte {
 	return C.GoBytes(unsafe.Pointer(this.avPacket.data), C.int(this.avPacket.size))
 }
 
+// Writes packet payload to w without copying it into Go memory. Implements io.WriterTo.
+func (this *Packet) WriteTo(w io.Writer) (int64, error) {
+	if this.avPacket.data == nil || this.avPacket.size <= 0 {
+		return 0, nil
+	}
+
+	size := int(this.avPacket.size)
+	data := (*[1 << 30]byte)(unsafe.Pointer(this.avPacket.data))[:size:size]
+
+	n, err := w.Write(data)
+
+	return int64(n), err
+}
+
+// Returns new packet, which references the same data buffer (it's copied, if
+// packet isn't reference counted). It's safe to pass the clone to another goroutine.
+// Clone should be released independently. Nil is returned on failure.
 func (this *Packet) Clone() *Packet {
 	np := NewPacket()
 
-	C.av_copy_packet(&np.avPacket, &this.avPacket)
+	// av_packet_clone() allocates AVPacket itself, so ref it into our own struct
+	if ret := C.av_packet_ref(&np.avPacket, &this.avPacket); int(ret) < 0 {
+		return nil
+	}
 
 	return np
 }
 
+// Converts pts, dts and duration from src time base into dst.
+func (this *Packet) Rescale(src, dst AVR) {
+	C.av_packet_rescale_ts(&this.avPacket, C.struct_AVRational(src.AVRational()), C.struct_AVRational(dst.AVRational()))
+}
+
+// Converts timestamps from in stream time base into out stream time base.
+// Stream index is not changed.
+func (this *Packet) RescaleFromTo(in, out *Stream) {
+	this.Rescale(in.TimeBase().AVR(), out.TimeBase().AVR())
+}
+
+// Returns a copy of side data of given kind (AV_PKT_DATA_*), false if packet doesn't have it.
+func (this *Packet) GetSideData(kind int) ([]byte, bool) {
+	var size C.int
+
+	data := C.av_packet_get_side_data(&this.avPacket, uint32(kind), &size)
+	if data == nil {
+		return nil, false
+	}
+
+	return C.GoBytes(unsafe.Pointer(data), size), true
+}
+
+func (this *Packet) SetSideData(kind int, data []byte) error {
+	dst := C.av_packet_new_side_data(&this.avPacket, uint32(kind), C.int(len(data)))
+	if dst == nil {
+		return errors.New(fmt.Sprintf("Unable to allocate %d bytes of side data", len(data)))
+	}
+
+	if len(data) > 0 {
+		C.memcpy(unsafe.Pointer(dst), unsafe.Pointer(&data[0]), C.size_t(len(data)))
+	}
+
+	return nil
+}
+
 func (this *Packet) Dump() {
 	fmt.Println(this.avPacket)
 	fmt.Println("pkt:{\n", "pts:", this.avPacket.pts, "\ndts:", this.avPacket.dts, "\ndata:", string(C.GoBytes(unsafe.Pointer(this.avPacket.data), 128)), "size:", this.avPacket.size, "\n}")
@@ -0,0 +1,63 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavcodec
+
+#include "libavcodec/avcodec.h"
+
+*/
+import "C"
+
+// Pool of packets, the same as FramePool, but for packets. Packets taken by Get
+// should be returned by Put instead of Release.
+type PacketPool struct {
+	packets chan *Packet
+	CgoMemoryManage
+}
+
+// Creates pool, which keeps at most size free packets.
+func NewPacketPool(size int) *PacketPool {
+	return &PacketPool{packets: make(chan *Packet, size)}
+}
+
+// Returns free packet from the pool or allocates new one if the pool is empty.
+func (this *PacketPool) Get() *Packet {
+	select {
+	case p := <-this.packets:
+		return p
+	default:
+		return NewPacket()
+	}
+}
+
+// Unreferences packet data and returns it to the pool. Packet is freed, if the pool is full.
+func (this *PacketPool) Put(p *Packet) {
+	if p == nil {
+		return
+	}
+
+	C.av_packet_unref(&p.avPacket)
+
+	select {
+	case this.packets <- p:
+	default:
+		p.Free()
+	}
+}
+
+// Returns number of free packets in the pool.
+func (this *PacketPool) Len() int {
+	return len(this.packets)
+}
+
+func (this *PacketPool) Free() {
+	for {
+		select {
+		case p := <-this.packets:
+			p.Free()
+		default:
+			return
+		}
+	}
+}
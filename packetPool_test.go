@@ -0,0 +1,46 @@
+package gmf
+
+import (
+	"log"
+	"testing"
+)
+
+func TestGetNewPacketsFromPool(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	pool := NewPacketPool(4)
+	defer Release(pool)
+
+	cnt := 0
+	for p := range inputCtx.GetNewPacketsFromPool(pool) {
+		if p.Size() == 0 {
+			t.Fatal("Expected packet with data")
+		}
+
+		cnt++
+		pool.Put(p)
+	}
+
+	if err := inputCtx.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reader may take next packet before previous one is returned
+	if pool.Len() < 1 || pool.Len() > 2 {
+		t.Fatalf("Expected 1 or 2 packets in pool, %d got\n", pool.Len())
+	}
+
+	log.Println(cnt, "packets read using", pool.Len(), "pooled packets")
+}
+
+func BenchmarkPacketPool(b *testing.B) {
+	pool := NewPacketPool(1)
+	defer Release(pool)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		pool.Put(pool.Get())
+	}
+}
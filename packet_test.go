@@ -1,8 +1,11 @@
 package gmf
 
 import (
+	"bytes"
+	"io"
 	"log"
 	"testing"
+	"time"
 )
 
 func TestFramesIterator(t *testing.T) {
@@ -74,3 +77,221 @@ func TestGetNextFrame(t *testing.T) {
 		Release(packet)
 	}
 }
+
+func TestCodecCtxDecode(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
+	cc := ist.CodecCtx()
+
+	total := 0
+
+	for packet := range inputCtx.GetNewPackets() {
+		if packet.StreamIndex() != ist.Index() {
+			Release(packet)
+			continue
+		}
+
+		frames, err := cc.Decode(packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, frame := range frames {
+			Release(frame)
+		}
+
+		total += len(frames)
+		Release(packet)
+	}
+
+	frames, err := cc.Decode(nil)
+	if err != io.EOF {
+		t.Fatalf("Expected io.EOF, '%v' got\n", err)
+	}
+
+	for _, frame := range frames {
+		Release(frame)
+	}
+
+	total += len(frames)
+
+	if total == 0 {
+		t.Fatal("Expected frames > 0")
+	}
+
+	log.Println(total, "frames decoded")
+}
+
+func TestPacketRescale(t *testing.T) {
+	p := NewPacket()
+	defer Release(p)
+
+	p.SetPts(25).SetDts(24).SetDuration(1)
+
+	p.Rescale(AVR{1, 25}, AVR{1, 90000})
+
+	if p.Pts() != 90000 || p.Dts() != 86400 || p.Duration() != 3600 {
+		t.Fatalf("Unexpected timestamps after rescale, pts: %d, dts: %d, duration: %d\n", p.Pts(), p.Dts(), p.Duration())
+	}
+}
+
+func TestPacketClone(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	packet := inputCtx.GetNextPacket()
+	clone := packet.Clone()
+
+	if clone == nil {
+		t.Fatal("Unable to clone packet")
+	}
+
+	Release(packet)
+
+	if clone.Size() <= 0 || len(clone.Data()) != clone.Size() {
+		t.Fatal("Expected clone data after original packet release")
+	}
+
+	Release(clone)
+}
+
+func TestPacketSideData(t *testing.T) {
+	p := NewPacket()
+	defer Release(p)
+
+	if _, ok := p.GetSideData(AV_PKT_DATA_A53_CC); ok {
+		t.Fatal("Expected no side data in empty packet")
+	}
+
+	if err := p.SetSideData(AV_PKT_DATA_A53_CC, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, ok := p.GetSideData(AV_PKT_DATA_A53_CC)
+	if !ok || len(data) != 3 || data[2] != 3 {
+		t.Fatalf("Unexpected side data: %v\n", data)
+	}
+}
+
+func TestPacketIsKey(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	keys := 0
+	for packet := range inputCtx.GetNewPackets() {
+		if packet.IsKeyPacket() {
+			keys++
+		}
+		Release(packet)
+	}
+
+	if keys == 0 {
+		t.Fatal("Expected key packets > 0")
+	}
+
+	log.Println(keys, "key packets found")
+}
+
+func TestPacketWriteTo(t *testing.T) {
+	inputCtx, err := NewInputCtx(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer inputCtx.CloseInputAndRelease()
+
+	packet := inputCtx.GetNextPacket()
+	defer Release(packet)
+
+	var b bytes.Buffer
+
+	n, err := packet.WriteTo(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if int(n) != packet.Size() || !bytes.Equal(b.Bytes(), packet.Data()) {
+		t.Fatalf("Expected %d bytes written, %d got\n", packet.Size(), n)
+	}
+}
+
+func TestNewPacketFromBytes(t *testing.T) {
+	data := []byte{0, 0, 0, 1, 0x65, 0x88}
+
+	p, err := NewPacketFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(p)
+
+	p.SetPts(10).SetDts(10).SetStreamIndex(1)
+
+	if !bytes.Equal(p.Data(), data) {
+		t.Fatalf("Expected %v, %v got\n", data, p.Data())
+	}
+
+	data[0] = 1
+
+	if p.Data()[0] != 0 {
+		t.Fatal("Expected packet data to be copied")
+	}
+
+	if p.Pts() != 10 || p.StreamIndex() != 1 {
+		t.Fatalf("Unexpected pts %d or stream index %d\n", p.Pts(), p.StreamIndex())
+	}
+}
+
+func TestPacketIsCorrupt(t *testing.T) {
+	p := NewPacket()
+	defer Release(p)
+
+	if p.IsCorrupt() {
+		t.Fatal("Expected new packet not to be corrupt")
+	}
+
+	p.SetFlags(p.Flags() | AV_PKT_FLAG_CORRUPT)
+
+	if !p.IsCorrupt() || p.IsKeyPacket() {
+		t.Fatalf("Expected only corrupt flag, %d got\n", p.Flags())
+	}
+}
+
+func TestPacketPtsSeconds(t *testing.T) {
+	ctx := NewCtx()
+	defer Release(ctx)
+
+	st := ctx.NewStream(nil)
+	if st == nil {
+		t.Fatal("Unable to create new stream")
+	}
+	st.SetTimeBase(AVR{1, 90000})
+
+	p := NewPacket()
+	defer Release(p)
+
+	p.SetPts(135000)
+
+	if p.PtsSeconds(st) != 1.5 || p.PtsTime(st) != 1500*time.Millisecond {
+		t.Fatalf("Expected 1.5s, %v got\n", p.PtsTime(st))
+	}
+
+	p.SetPts(AV_NOPTS_VALUE)
+
+	if p.PtsSeconds(st) != 0 {
+		t.Fatalf("Expected 0 for unset pts, %v got\n", p.PtsSeconds(st))
+	}
+}
@@ -0,0 +1,83 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavformat libavcodec
+
+#include "libavformat/avformat.h"
+#include "libavcodec/avcodec.h"
+
+*/
+import "C"
+
+import (
+	"time"
+)
+
+// Media file description, returned by ProbeFile.
+// It holds no C pointers, so it's safe to use after context is released.
+type MediaInfo struct {
+	FormatName string
+	Duration   time.Duration
+	BitRate    int64
+	Streams    []StreamInfo
+}
+
+type StreamInfo struct {
+	Index     int
+	Type      int32
+	CodecName string
+
+	// video
+	Width  int
+	Height int
+
+	// audio
+	SampleRate int
+	Channels   int
+}
+
+func ProbeFile(path string) (*MediaInfo, error) {
+	ctx, err := NewInputCtx(path)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.CloseInputAndRelease()
+
+	result := &MediaInfo{
+		FormatName: C.GoString(ctx.avCtx.iformat.name),
+		Duration:   ctx.Duration(),
+		BitRate:    int64(ctx.avCtx.bit_rate),
+		Streams:    make([]StreamInfo, 0, ctx.StreamsCnt()),
+	}
+
+	for i := 0; i < ctx.StreamsCnt(); i++ {
+		st, err := ctx.GetStream(i)
+		if err != nil {
+			return nil, err
+		}
+
+		// decoder isn't opened here, only parameters found by demuxer are read
+		par := st.avStream.codecpar
+
+		info := StreamInfo{
+			Index:     i,
+			Type:      int32(par.codec_type),
+			CodecName: C.GoString(C.avcodec_get_name(par.codec_id)),
+		}
+
+		switch info.Type {
+		case AVMEDIA_TYPE_VIDEO:
+			info.Width = int(par.width)
+			info.Height = int(par.height)
+
+		case AVMEDIA_TYPE_AUDIO:
+			info.SampleRate = int(par.sample_rate)
+			info.Channels = int(par.channels)
+		}
+
+		result.Streams = append(result.Streams, info)
+	}
+
+	return result, nil
+}
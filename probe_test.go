@@ -0,0 +1,25 @@
+package gmf
+
+import (
+	"log"
+	"testing"
+)
+
+func TestProbeFile(t *testing.T) {
+	info, err := ProbeFile(inputSampleFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(info.Streams) == 0 {
+		t.Fatal("Expected streams > 0")
+	}
+
+	for _, st := range info.Streams {
+		if st.Type == AVMEDIA_TYPE_VIDEO && (st.Width != inputSampleWidth || st.Height != inputSampleHeight) {
+			t.Fatalf("Expected dimension = %dx%d, %dx%d got\n", inputSampleWidth, inputSampleHeight, st.Width, st.Height)
+		}
+	}
+
+	log.Printf("%s, duration: %v, bitrate: %d, streams: %v\n", info.FormatName, info.Duration, info.BitRate, info.Streams)
+}
@@ -0,0 +1,52 @@
+package gmf
+
+import (
+	"time"
+)
+
+// Generates presentation timestamps for constant frame rate encoding.
+type PtsGenerator struct {
+	timeBase AVR
+	fps      AVR
+	index    int64
+	start    time.Time
+	last     int64
+}
+
+// Creates generator of timestamps in timeBase units for frames going with fps rate.
+func NewPtsGenerator(timeBase, fps AVR) *PtsGenerator {
+	return &PtsGenerator{timeBase: timeBase, fps: fps, last: AV_NOPTS_VALUE}
+}
+
+// Returns pts of the next frame.
+func (this *PtsGenerator) Next() int64 {
+	pts := this.fps.Invert().RescaleQ(this.index, this.timeBase)
+	this.index++
+	this.last = pts
+
+	return pts
+}
+
+// Returns pts of the frame captured at t, relative to the first call.
+// Result is always greater than previous one.
+func (this *PtsGenerator) FromRealTime(t time.Time) int64 {
+	if this.start.IsZero() {
+		this.start = t
+	}
+
+	pts := AVR{1, int(time.Second / time.Microsecond)}.RescaleQ(int64(t.Sub(this.start)/time.Microsecond), this.timeBase)
+	if !IsNoPts(this.last) && pts <= this.last {
+		pts = this.last + 1
+	}
+
+	this.last = pts
+
+	return pts
+}
+
+// Resets generator to its initial state.
+func (this *PtsGenerator) Reset() {
+	this.index = 0
+	this.start = time.Time{}
+	this.last = AV_NOPTS_VALUE
+}
@@ -0,0 +1,34 @@
+package gmf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPtsGenerator(t *testing.T) {
+	gen := NewPtsGenerator(AVR{1, 90000}, AVR{25, 1})
+
+	for i := 0; i < 3; i++ {
+		if pts := gen.Next(); pts != int64(i*3600) {
+			t.Fatalf("Expected pts %d, %d got\n", i*3600, pts)
+		}
+	}
+}
+
+func TestPtsGeneratorFromRealTime(t *testing.T) {
+	gen := NewPtsGenerator(AVR{1, 1000}, AVR{25, 1})
+	start := time.Now()
+
+	if pts := gen.FromRealTime(start); pts != 0 {
+		t.Fatalf("Expected pts 0, %d got\n", pts)
+	}
+
+	if pts := gen.FromRealTime(start.Add(40 * time.Millisecond)); pts != 40 {
+		t.Fatalf("Expected pts 40, %d got\n", pts)
+	}
+
+	// the same time shouldn't produce the same pts
+	if pts := gen.FromRealTime(start.Add(40 * time.Millisecond)); pts != 41 {
+		t.Fatalf("Expected pts 41, %d got\n", pts)
+	}
+}
@@ -0,0 +1,233 @@
+package gmf
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Input, which is reopened, when reading fails with error other than end of stream,
+// e.g. dropped RTMP or RTSP connection. Typical usage:
+//
+//	input, err := NewReconnectingInput("rtsp://camera/stream", nil)
+//	...
+//	input.SetMaxRetries(10).SetBackoff(time.Second, 30*time.Second)
+//	defer Release(input)
+//
+//	for packet := range input.GetNewPackets() {
+//		...
+//		Release(packet)
+//	}
+//
+//	if err := input.Err(); err != nil {
+//		// retries are exhausted
+//	}
+//
+// Context is replaced on reconnect, so streams and decoders taken from the previous one
+// are invalid. Use SetOnReconnect to reinitialize them.
+type ReconnectingInput struct {
+	url         string
+	opts        []Pair
+	ctx         *FmtCtx
+	maxRetries  int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	reseek      bool
+	onReconnect func(*FmtCtx)
+	reconnects  int
+	lastStream  int
+	lastTs      int64
+	err         error
+	CgoMemoryManage
+}
+
+// Opens url with options, they are passed to each reopening. By default 3 retries are made
+// with backoff starting from 1 second and doubling up to 30 seconds.
+func NewReconnectingInput(url string, opts *Dict) (*ReconnectingInput, error) {
+	this := &ReconnectingInput{
+		url:        url,
+		maxRetries: 3,
+		backoff:    time.Second,
+		maxBackoff: 30 * time.Second,
+		lastStream: -1,
+		lastTs:     AV_NOPTS_VALUE,
+	}
+
+	if opts != nil {
+		this.opts = opts.Pairs()
+	}
+
+	ctx, err := this.open()
+	if err != nil {
+		return nil, err
+	}
+
+	this.ctx = ctx
+
+	return this, nil
+}
+
+// Sets max number of reopening attempts in a row, negative value means unlimited.
+func (this *ReconnectingInput) SetMaxRetries(n int) *ReconnectingInput {
+	this.maxRetries = n
+	return this
+}
+
+// Sets delay before the first reopening attempt, it's doubled for every next one up to max.
+func (this *ReconnectingInput) SetBackoff(initial, max time.Duration) *ReconnectingInput {
+	this.backoff = initial
+	this.maxBackoff = max
+	return this
+}
+
+// If set, reopened input is seeked to the position of the last read packet.
+// It's ignored for inputs which don't support seeking.
+func (this *ReconnectingInput) SetReseek(val bool) *ReconnectingInput {
+	this.reseek = val
+	return this
+}
+
+// Sets function, which is called with new context after successful reconnect.
+func (this *ReconnectingInput) SetOnReconnect(fn func(*FmtCtx)) *ReconnectingInput {
+	this.onReconnect = fn
+	return this
+}
+
+// Returns current input context.
+func (this *ReconnectingInput) Ctx() *FmtCtx {
+	return this.ctx
+}
+
+// Returns number of successful reconnects.
+func (this *ReconnectingInput) Reconnects() int {
+	return this.reconnects
+}
+
+// Returns error, which stopped reading after all retries, or nil if the end of input was reached.
+func (this *ReconnectingInput) Err() error {
+	return this.err
+}
+
+// Returns next packet, reconnecting if it's needed, or nil at the end of input
+// or when retries are exhausted.
+func (this *ReconnectingInput) GetNextPacket() *Packet {
+	for this.ctx != nil {
+		if p := this.ctx.GetNextPacket(); p != nil {
+			this.track(p)
+			return p
+		}
+
+		readErr := this.ctx.Err()
+		if readErr == nil {
+			return nil
+		}
+
+		if err := this.reconnect(); err != nil {
+			this.err = errors.New(fmt.Sprintf("%s, reconnect failed: %s", readErr, err))
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (this *ReconnectingInput) GetNewPackets() chan *Packet {
+	yield := make(chan *Packet)
+
+	go func() {
+		defer close(yield)
+
+		for p := this.GetNextPacket(); p != nil; p = this.GetNextPacket() {
+			yield <- p
+		}
+	}()
+
+	return yield
+}
+
+func (this *ReconnectingInput) track(p *Packet) {
+	ts := p.Pts()
+	if IsNoPts(ts) {
+		ts = p.Dts()
+	}
+
+	if !IsNoPts(ts) {
+		this.lastStream = p.StreamIndex()
+		this.lastTs = ts
+	}
+}
+
+func (this *ReconnectingInput) open() (*FmtCtx, error) {
+	var opts *Dict
+
+	if len(this.opts) > 0 {
+		opts = NewDict(this.opts)
+		defer Release(opts)
+	}
+
+	return NewInputCtxWithOptions(this.url, opts)
+}
+
+func (this *ReconnectingInput) reconnect() error {
+	this.ctx.CloseInputAndRelease()
+	this.ctx = nil
+
+	delay := this.backoff
+
+	var err error
+
+	for i := 0; this.maxRetries < 0 || i < this.maxRetries; i++ {
+		time.Sleep(delay)
+
+		if delay *= 2; delay > this.maxBackoff {
+			delay = this.maxBackoff
+		}
+
+		var ctx *FmtCtx
+		if ctx, err = this.open(); err != nil {
+			continue
+		}
+
+		this.ctx = ctx
+		this.reconnects++
+		this.seekToLast()
+
+		if this.onReconnect != nil {
+			this.onReconnect(ctx)
+		}
+
+		return nil
+	}
+
+	if err == nil {
+		err = errors.New("no retries allowed")
+	}
+
+	return err
+}
+
+func (this *ReconnectingInput) seekToLast() {
+	if !this.reseek || IsNoPts(this.lastTs) {
+		return
+	}
+
+	ist, err := this.ctx.GetStream(this.lastStream)
+	if err != nil {
+		return
+	}
+
+	ts := this.lastTs
+	if start := ist.StartTime(); !IsNoPts(start) {
+		ts -= start
+	}
+
+	// error means the input isn't seekable, reading continues from its beginning
+	this.ctx.SeekFrameAt(toDuration(ts, ist.TimeBase()), this.lastStream)
+}
+
+func (this *ReconnectingInput) Free() {
+	if this.ctx != nil {
+		this.ctx.CloseInputAndRelease()
+		this.ctx = nil
+	}
+}
@@ -0,0 +1,36 @@
+package gmf
+
+import (
+	"log"
+	"testing"
+)
+
+func TestReconnectingInput(t *testing.T) {
+	input, err := NewReconnectingInput(inputSampleFilename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(input)
+
+	cnt := 0
+	for packet := range input.GetNewPackets() {
+		cnt++
+		Release(packet)
+	}
+
+	if cnt == 0 {
+		t.Fatal("Expected packets > 0")
+	}
+
+	if input.Err() != nil || input.Reconnects() != 0 {
+		t.Fatalf("Expected no errors and reconnects, '%v', %d got\n", input.Err(), input.Reconnects())
+	}
+
+	log.Println(cnt, "packets have been read")
+}
+
+func TestReconnectingInputOpenError(t *testing.T) {
+	if _, err := NewReconnectingInput("not-existing-file.mp4", nil); err == nil {
+		t.Fatal("Expected error for not existing input")
+	}
+}
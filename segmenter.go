@@ -0,0 +1,188 @@
+package gmf
+
+/*
+
+#cgo pkg-config: libavcodec libavformat
+
+#include "libavcodec/avcodec.h"
+#include "libavformat/avformat.h"
+
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type segmenterStream struct {
+	par      *CodecParameters
+	timeBase AVRational
+}
+
+// Splits packets into multiple output files, e.g. for HLS or DASH.
+// New segment is started on a key packet of the first video stream (or any stream,
+// if there is no video), once minDuration of current segment has elapsed.
+//
+// E.g.:
+//
+//	seg, _ := NewSegmenter("seg%03d.ts", 4*time.Second)
+//	seg.AddStream(ist)
+//
+//	for packet := range inputCtx.GetNewPackets() {
+//		seg.WritePacket(packet)
+//		Release(packet)
+//	}
+//
+//	seg.Close()
+type Segmenter struct {
+	pattern     string
+	minDuration time.Duration
+	streams     []segmenterStream
+	splitIndex  int
+	ctx         *FmtCtx
+	segments    int
+	segStart    time.Duration
+	CgoMemoryManage
+}
+
+// Pattern is formatted with segment number to get the file name, output format is guessed by it.
+func NewSegmenter(pattern string, minDuration time.Duration) (*Segmenter, error) {
+	if FindOutputFmt("", fmt.Sprintf(pattern, 0), "") == nil {
+		return nil, errors.New(fmt.Sprintf("unable to guess output format for pattern '%s'", pattern))
+	}
+
+	return &Segmenter{
+		pattern:     pattern,
+		minDuration: minDuration,
+		streams:     make([]segmenterStream, 0),
+		splitIndex:  -1,
+	}, nil
+}
+
+// Adds output stream with parameters of ist, returns its index. Packets passed to WritePacket
+// should have this index and timestamps in ist time base. Streams should be added before
+// the first packet is written.
+func (this *Segmenter) AddStream(ist *Stream) (int, error) {
+	if this.ctx != nil {
+		return -1, errors.New("streams should be added before writing packets")
+	}
+
+	par := NewCodecParameters()
+	if par == nil {
+		return -1, errors.New("unable to allocate codec parameters")
+	}
+
+	if averr := C.avcodec_parameters_copy(par.avCodecPar, ist.avStream.codecpar); averr < 0 {
+		Release(par)
+		return -1, errors.New(fmt.Sprintf("Unable to copy codec parameters: %s", AvError(int(averr))))
+	}
+
+	idx := len(this.streams)
+	this.streams = append(this.streams, segmenterStream{par: par, timeBase: ist.TimeBase()})
+
+	if par.CodecType() == AVMEDIA_TYPE_VIDEO && this.splitIndex < 0 {
+		this.splitIndex = idx
+	}
+
+	return idx, nil
+}
+
+// Writes a copy of p into current segment, starting new one if needed.
+func (this *Segmenter) WritePacket(p *Packet) error {
+	idx := p.StreamIndex()
+	if idx < 0 || idx >= len(this.streams) {
+		return errors.New(fmt.Sprintf("unknown stream index %d", idx))
+	}
+
+	st := this.streams[idx]
+	ts := toDuration(p.Pts(), st.timeBase)
+
+	if this.ctx == nil {
+		if err := this.openSegment(ts); err != nil {
+			return err
+		}
+	} else if p.IsKeyPacket() && (this.splitIndex < 0 || this.splitIndex == idx) && ts-this.segStart >= this.minDuration {
+		this.closeSegment()
+
+		if err := this.openSegment(ts); err != nil {
+			return err
+		}
+	}
+
+	clone := p.Clone()
+	if clone == nil {
+		return errors.New("unable to copy packet")
+	}
+	defer Release(clone)
+
+	ost, err := this.ctx.GetStream(idx)
+	if err != nil {
+		return err
+	}
+
+	clone.Rescale(st.timeBase.AVR(), ost.TimeBase().AVR())
+
+	return this.ctx.WritePacket(clone)
+}
+
+// Returns number of segments started so far.
+func (this *Segmenter) Segments() int {
+	return this.segments
+}
+
+// Finishes current segment.
+func (this *Segmenter) Close() {
+	this.closeSegment()
+}
+
+func (this *Segmenter) openSegment(start time.Duration) error {
+	ctx, err := NewOutputCtx(fmt.Sprintf(this.pattern, this.segments))
+	if err != nil {
+		return err
+	}
+
+	for _, st := range this.streams {
+		ost, err := ctx.AddStreamWithCodecPar(st.par)
+		if err != nil {
+			ctx.CloseOutputAndRelease()
+			return err
+		}
+
+		// let the muxer choose valid tag
+		ost.avStream.codecpar.codec_tag = 0
+		ost.avStream.time_base = C.struct_AVRational(st.timeBase)
+	}
+
+	if err := ctx.WriteHeader(); err != nil {
+		ctx.CloseOutputAndRelease()
+		return err
+	}
+
+	this.ctx = ctx
+	this.segStart = start
+	this.segments++
+
+	return nil
+}
+
+func (this *Segmenter) closeSegment() {
+	if this.ctx == nil {
+		return
+	}
+
+	// trailer is written by CloseOutputAndRelease
+	this.ctx.CloseOutputAndRelease()
+	this.ctx = nil
+}
+
+func (this *Segmenter) Free() {
+	this.closeSegment()
+
+	for _, st := range this.streams {
+		Release(st.par)
+	}
+
+	this.streams = nil
+}
@@ -0,0 +1,60 @@
+package gmf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSegmenter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gmf-segmenter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	seg, err := NewSegmenter(filepath.Join(dir, "seg%03d.ts"), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ist := range inputCtx.Streams() {
+		if _, err := seg.AddStream(ist); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for packet := range inputCtx.GetNewPackets() {
+		if err := seg.WritePacket(packet); err != nil {
+			t.Fatal(err)
+		}
+		Release(packet)
+	}
+
+	Release(seg)
+
+	if seg.Segments() < 1 {
+		t.Fatalf("Expected at least 1 segment, %d got\n", seg.Segments())
+	}
+
+	for i := 0; i < seg.Segments(); i++ {
+		if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("seg%03d.ts", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log.Printf("%d segments have been written\n", seg.Segments())
+}
+
+func TestSegmenterBadPattern(t *testing.T) {
+	if _, err := NewSegmenter("seg%03d.unknown-ext", time.Second); err == nil {
+		t.Fatal("Expected error for unknown format")
+	}
+}
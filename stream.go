@@ -2,20 +2,47 @@ package gmf
 
 /*
 
-#cgo pkg-config: libavformat
+#cgo pkg-config: libavformat libavutil
 
 #include "libavformat/avformat.h"
+#include "libavutil/display.h"
+
+static double gmf_stream_rotation(AVStream *st) {
+	uint8_t *matrix = av_stream_get_side_data(st, AV_PKT_DATA_DISPLAYMATRIX, NULL);
+	if (!matrix) {
+		return 0;
+	}
+
+	return av_display_rotation_get((int32_t *)matrix);
+}
 
 */
 import "C"
 
 import (
 	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	AV_DISPOSITION_DEFAULT          int = C.AV_DISPOSITION_DEFAULT
+	AV_DISPOSITION_DUB              int = C.AV_DISPOSITION_DUB
+	AV_DISPOSITION_ORIGINAL         int = C.AV_DISPOSITION_ORIGINAL
+	AV_DISPOSITION_COMMENT          int = C.AV_DISPOSITION_COMMENT
+	AV_DISPOSITION_LYRICS           int = C.AV_DISPOSITION_LYRICS
+	AV_DISPOSITION_KARAOKE          int = C.AV_DISPOSITION_KARAOKE
+	AV_DISPOSITION_FORCED           int = C.AV_DISPOSITION_FORCED
+	AV_DISPOSITION_HEARING_IMPAIRED int = C.AV_DISPOSITION_HEARING_IMPAIRED
+	AV_DISPOSITION_VISUAL_IMPAIRED  int = C.AV_DISPOSITION_VISUAL_IMPAIRED
+	AV_DISPOSITION_CLEAN_EFFECTS    int = C.AV_DISPOSITION_CLEAN_EFFECTS
+	AV_DISPOSITION_ATTACHED_PIC     int = C.AV_DISPOSITION_ATTACHED_PIC
 )
 
 type Stream struct {
 	avStream *C.struct_AVStream
 	cc       *CodecCtx
+	ccOwned  bool
 	Pts      int64
 	CgoMemoryManage
 }
@@ -59,6 +86,21 @@ func (this *Stream) CodecCtx() *CodecCtx {
 	return this.cc
 }
 
+// Returns parameters of the stream, they are owned by the stream.
+func (this *Stream) CodecPar() *CodecParameters {
+	return &CodecParameters{avCodecPar: this.avStream.codecpar}
+}
+
+// Fills stream parameters from codec context, e.g. opened encoder. It should be called
+// before WriteHeader instead of SetCodecCtx, which relies on deprecated AVStream.codec.
+func (this *Stream) SetCodecParFromCtx(cc *CodecCtx) error {
+	if cc == nil {
+		return ErrNilContext
+	}
+
+	return this.CodecPar().FromContext(cc)
+}
+
 func (this *Stream) SetCodecCtx(cc *CodecCtx) {
 	if cc == nil {
 		// don't sure that it should panic...
@@ -85,14 +127,47 @@ func (this *Stream) Id() int {
 	return int(this.avStream.id)
 }
 
-func (this *Stream) NbFrames() int {
-	return int(this.avStream.nb_frames)
+// Returns number of frames reported by container, 0 if it's unknown.
+func (this *Stream) NbFrames() int64 {
+	return int64(this.avStream.nb_frames)
 }
 
+// Returns NbFrames or, if it's unknown, estimation based on stream duration and frame rate.
+// Returns 0 if both are unknown.
+func (this *Stream) EstimatedFrames() int64 {
+	if n := this.NbFrames(); n > 0 {
+		return n
+	}
+
+	duration := int64(this.avStream.duration)
+	if duration <= 0 || IsNoPts(duration) {
+		return 0
+	}
+
+	fps := this.AvgFrameRate()
+	if fps.Num <= 0 || fps.Den <= 0 {
+		fps = this.RFrameRate()
+	}
+
+	if fps.Num <= 0 || fps.Den <= 0 {
+		return 0
+	}
+
+	return this.TimeBase().AVR().RescaleQ(duration, fps.Invert())
+}
+
+// Returns stream time base. For output streams muxer can change it in WriteHeader,
+// so packets should be rescaled with the value read after it.
 func (this *Stream) TimeBase() AVRational {
 	return AVRational(this.avStream.time_base)
 }
 
+// Requests time base for output stream, e.g. 1/90000 for mpegts. It should be called before WriteHeader.
+func (this *Stream) SetTimeBase(val AVR) *Stream {
+	this.avStream.time_base = C.struct_AVRational(val.AVRational())
+	return this
+}
+
 func (this *Stream) Type() int32 {
 	return this.CodecCtx().Type()
 }
@@ -101,10 +176,82 @@ func (this *Stream) IsAudio() bool {
 	return (this.Type() == AVMEDIA_TYPE_AUDIO)
 }
 
+// Returns AV_DISPOSITION_* flags of the stream.
+func (this *Stream) Disposition() int {
+	return int(this.avStream.disposition)
+}
+
+// Sets AV_DISPOSITION_* flags, e.g. to mark default audio track. Should be called before WriteHeader.
+func (this *Stream) SetDisposition(flags int) *Stream {
+	this.avStream.disposition = C.int(flags)
+	return this
+}
+
+// Returns average frame rate, {0, 0} if it's unknown.
+func (this *Stream) AvgFrameRate() AVR {
+	return AVRational(this.avStream.avg_frame_rate).AVR()
+}
+
+// Returns the lowest frame rate, which all timestamps can be represented accurately with.
+func (this *Stream) RFrameRate() AVR {
+	return AVRational(this.avStream.r_frame_rate).AVR()
+}
+
 func (this *Stream) IsVideo() bool {
 	return (this.Type() == AVMEDIA_TYPE_VIDEO)
 }
 
-func (this *Stream) Duration() int64 {
-	return int64(this.avStream.duration)
+// Returns 0 if duration is unknown.
+func (this *Stream) Duration() time.Duration {
+	return toDuration(int64(this.avStream.duration), this.TimeBase())
+}
+
+func (this *Stream) StartTime() int64 {
+	return int64(this.avStream.start_time)
+}
+
+// Returns stream bit rate in bits per second, for raw audio it's calculated from sample parameters.
+// Returns 0 if it's unknown.
+func (this *Stream) BitRate() int64 {
+	par := this.avStream.codecpar
+	if par.bit_rate > 0 {
+		return int64(par.bit_rate)
+	}
+
+	if par.codec_type == C.AVMEDIA_TYPE_AUDIO && par.bits_per_coded_sample > 0 {
+		return int64(par.bits_per_coded_sample) * int64(par.sample_rate) * int64(par.channels)
+	}
+
+	return 0
+}
+
+// Returns a copy of stream metadata, it should be released by caller.
+func (this *Stream) Metadata() *Dict {
+	return newDictFromAVDict(this.avStream.metadata)
+}
+
+// Copies d into stream metadata. Should be called before WriteHeader.
+func (this *Stream) SetMetadata(d *Dict) {
+	if d == nil {
+		return
+	}
+
+	C.av_dict_copy(&this.avStream.metadata, d.avDict, 0)
+}
+
+// Returns clockwise rotation in degrees [0, 360), which should be applied to display the video
+// correctly, or 0 if stream has no display matrix.
+func (this *Stream) Rotation() float64 {
+	// av_display_rotation_get() returns counterclockwise angle
+	theta := -float64(C.gmf_stream_rotation(this.avStream))
+	if math.IsNaN(theta) {
+		return 0
+	}
+
+	theta = math.Mod(theta, 360)
+	if theta < 0 {
+		theta += 360
+	}
+
+	return theta
 }
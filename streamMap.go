@@ -0,0 +1,54 @@
+package gmf
+
+// Maps input streams to output ones for remuxing.
+//
+//	sm := NewStreamMap()
+//	sm.Add(ist, ost)
+//
+//	for packet := range inputCtx.GetNewPackets() {
+//		if sm.Apply(packet) {
+//			outputCtx.WritePacket(packet)
+//		}
+//		Release(packet)
+//	}
+type StreamMap struct {
+	streams map[int]streamMapEntry
+}
+
+type streamMapEntry struct {
+	in  *Stream
+	out *Stream
+}
+
+func NewStreamMap() *StreamMap {
+	return &StreamMap{streams: make(map[int]streamMapEntry)}
+}
+
+// Maps inStream to outStream, previous mapping of inStream is replaced.
+func (this *StreamMap) Add(inStream, outStream *Stream) *StreamMap {
+	this.streams[inStream.Index()] = streamMapEntry{in: inStream, out: outStream}
+	return this
+}
+
+// Returns output stream index for input one, false if it isn't mapped.
+func (this *StreamMap) Get(inIndex int) (int, bool) {
+	e, ok := this.streams[inIndex]
+	if !ok {
+		return -1, false
+	}
+
+	return e.out.Index(), true
+}
+
+// Sets output stream index of p and rescales its timestamps into output stream time base.
+// Returns false and leaves p untouched, if its stream isn't mapped.
+func (this *StreamMap) Apply(p *Packet) bool {
+	e, ok := this.streams[p.StreamIndex()]
+	if !ok {
+		return false
+	}
+
+	p.SetStreamIndex(e.out.Index()).RescaleFromTo(e.in, e.out)
+
+	return true
+}
@@ -0,0 +1,53 @@
+package gmf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStreamMap(t *testing.T) {
+	inputCtx := assert(NewInputCtx(inputSampleFilename)).(*FmtCtx)
+	defer inputCtx.CloseInputAndRelease()
+
+	ist := assert(inputCtx.GetBestStream(AVMEDIA_TYPE_VIDEO)).(*Stream)
+
+	var b bytes.Buffer
+
+	outputCtx := assert(NewOutputCtxToWriter(&b, "mpegts")).(*FmtCtx)
+	defer outputCtx.CloseOutputAndRelease()
+
+	ost := assert(outputCtx.AddStreamWithCodecPar(ist.CodecPar())).(*Stream)
+	ost.avStream.codecpar.codec_tag = 0
+
+	if err := outputCtx.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+
+	sm := NewStreamMap().Add(ist, ost)
+
+	if idx, ok := sm.Get(ist.Index()); !ok || idx != ost.Index() {
+		t.Fatalf("Expected output index %d, %d got\n", ost.Index(), idx)
+	}
+
+	for packet := inputCtx.GetNextPacket(); packet != nil; packet = inputCtx.GetNextPacket() {
+		if packet.StreamIndex() != ist.Index() {
+			if sm.Apply(packet) {
+				t.Fatalf("Expected packet of stream %d not to be mapped\n", packet.StreamIndex())
+			}
+			Release(packet)
+			continue
+		}
+
+		pts := RescaleQ(packet.Pts(), ist.TimeBase(), ost.TimeBase())
+
+		if !sm.Apply(packet) {
+			t.Fatal("Expected packet to be mapped")
+		}
+
+		if packet.StreamIndex() != ost.Index() || packet.Pts() != pts {
+			t.Fatalf("Expected stream %d and pts %d, %d and %d got\n", ost.Index(), pts, packet.StreamIndex(), packet.Pts())
+		}
+
+		Release(packet)
+	}
+}
@@ -56,7 +56,139 @@ func TestStreamInputCtx(t *testing.T) {
 		t.Fatalf("Expected dimension = %dx%d, %dx%d got\n", inputSampleWidth, inputSampleHeight, ist.CodecCtx().Width(), ist.CodecCtx().Height())
 	}
 
+	if ist.Rotation() != 0 {
+		t.Fatalf("Expected rotation 0, %v got\n", ist.Rotation())
+	}
+
+	if fr := ist.AvgFrameRate(); fr.Num <= 0 || fr.Den <= 0 {
+		t.Fatalf("Expected valid average frame rate, %v got\n", fr)
+	}
+
+	if fr := ist.RFrameRate(); fr.Num <= 0 || fr.Den <= 0 {
+		t.Fatalf("Expected valid real frame rate, %v got\n", fr)
+	}
+
+	if ist.EstimatedFrames() <= 0 {
+		t.Fatalf("Expected positive frames number, %d got\n", ist.EstimatedFrames())
+	}
+
+	md := ist.Metadata()
+	defer Release(md)
+
+	log.Println("Input stream metadata:", md.Pairs())
+
+	par := ist.CodecPar()
+	if par.Width() != inputSampleWidth || par.Height() != inputSampleHeight {
+		t.Fatalf("Expected codecpar dimension = %dx%d, %dx%d got\n", inputSampleWidth, inputSampleHeight, par.Width(), par.Height())
+	}
+
+	outputCtx, err := NewOutputCtxWithFormatName("", "mpegts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(outputCtx)
+
+	ost, err := outputCtx.AddStreamWithCodecPar(par)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ost.CodecPar().CodecId() != par.CodecId() {
+		t.Fatalf("Expected codec id %d, %d got\n", par.CodecId(), ost.CodecPar().CodecId())
+	}
+
+	streams := inputCtx.Streams()
+	if len(streams) != inputCtx.StreamCount() {
+		t.Fatalf("Expected %d streams, %d got\n", inputCtx.StreamCount(), len(streams))
+	}
+
+	for i, st := range streams {
+		if st.Index() != i {
+			t.Fatalf("Expected stream index %d, %d got\n", i, st.Index())
+		}
+	}
+
 	log.Printf("Input stream is OK, cnt: %d, %dx%d\n", inputCtx.StreamsCnt(), ist.CodecCtx().Width(), ist.CodecCtx().Height())
 
 	inputCtx.CloseInputAndRelease()
 }
+
+func TestStreamMetadata(t *testing.T) {
+	ctx := NewCtx()
+	defer Release(ctx)
+
+	st := ctx.NewStream(nil)
+	if st == nil {
+		t.Fatal("Unable to create new stream")
+	}
+
+	d := NewDict([]Pair{{"language", "eng"}})
+	defer Release(d)
+
+	st.SetMetadata(d)
+
+	md := st.Metadata()
+	defer Release(md)
+
+	if md.Get("language") != "eng" {
+		t.Fatalf("Expected language 'eng', '%s' got\n", md.Get("language"))
+	}
+}
+
+func TestStreamDisposition(t *testing.T) {
+	ctx := NewCtx()
+	defer Release(ctx)
+
+	st := ctx.NewStream(nil)
+	if st == nil {
+		t.Fatal("Unable to create new stream")
+	}
+
+	st.SetDisposition(AV_DISPOSITION_DEFAULT | AV_DISPOSITION_FORCED)
+
+	if st.Disposition()&AV_DISPOSITION_FORCED == 0 || st.Disposition()&AV_DISPOSITION_DEFAULT == 0 {
+		t.Fatalf("Expected default and forced disposition, %d got\n", st.Disposition())
+	}
+}
+
+func TestStreamSetCodecParFromCtx(t *testing.T) {
+	ctx := NewCtx()
+	defer Release(ctx)
+
+	c := assert(FindEncoder(AV_CODEC_ID_MPEG4)).(*Codec)
+
+	cc := NewCodecCtx(c).SetTimeBase(AVR{1, 25}).SetDimension(320, 200).SetPixFmt(AV_PIX_FMT_YUV420P)
+	defer Release(cc)
+
+	if err := cc.Open(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	st := ctx.NewStream(nil)
+	if st == nil {
+		t.Fatal("Unable to create new stream")
+	}
+
+	if err := st.SetCodecParFromCtx(cc); err != nil {
+		t.Fatal(err)
+	}
+
+	par := st.CodecPar()
+	if par.CodecId() != AV_CODEC_ID_MPEG4 || par.Width() != 320 || par.Height() != 200 {
+		t.Fatalf("Expected mpeg4 320x200 parameters, codec %d %dx%d got\n", par.CodecId(), par.Width(), par.Height())
+	}
+}
+
+func TestStreamSetTimeBase(t *testing.T) {
+	ctx := NewCtx()
+	defer Release(ctx)
+
+	st := ctx.NewStream(nil)
+	if st == nil {
+		t.Fatal("Unable to create new stream")
+	}
+
+	if tb := st.SetTimeBase(AVR{1, 90000}).TimeBase().AVR(); tb.Num != 1 || tb.Den != 90000 {
+		t.Fatalf("Expected time base 1/90000, %v got\n", tb)
+	}
+}
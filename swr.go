@@ -5,23 +5,39 @@ package gmf
 
 /*
 
-#cgo pkg-config: libswresample
+#cgo pkg-config: libswresample libavutil
 
 #include "libswresample/swresample.h"
 #include <libavcodec/avcodec.h>
+#include <libavutil/channel_layout.h>
 #include <libavutil/frame.h>
+#include <libavutil/mathematics.h>
 
 int gmf_sw_resample(SwrContext* ctx, AVFrame*dstFrame, AVFrame*srcFrame){
 	return swr_convert(ctx, dstFrame->data, dstFrame->nb_samples,
 		(const uint8_t **)srcFrame->data, srcFrame->nb_samples);
 }
 
+int gmf_sw_resample_flush(SwrContext* ctx, AVFrame*dstFrame){
+	return swr_convert(ctx, dstFrame->data, dstFrame->nb_samples, NULL, 0);
+}
+
 */
 import "C"
 
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
 type SwrCtx struct {
-	swrCtx *C.struct_SwrContext
-	cc     *CodecCtx
+	swrCtx    *C.struct_SwrContext
+	cc        *CodecCtx
+	inRate    int
+	outRate   int
+	outLayout int64
+	outFmt    int32
 	CgoMemoryManage
 }
 
@@ -39,6 +55,32 @@ func NewSwrCtx(options []*Option, cc *CodecCtx) *SwrCtx {
 	return this
 }
 
+// Creates resampler without options list, output frames are produced by ConvertFrame.
+func NewSwrCtxWithParams(inLayout, outLayout int64, inRate, outRate int, inFmt, outFmt int32) (*SwrCtx, error) {
+	ctx := C.swr_alloc_set_opts(nil,
+		C.int64_t(outLayout), outFmt, C.int(outRate),
+		C.int64_t(inLayout), inFmt, C.int(inRate),
+		0, nil)
+	if ctx == nil {
+		return nil, errors.New("unable to allocate swr context")
+	}
+
+	this := &SwrCtx{
+		swrCtx:    ctx,
+		inRate:    inRate,
+		outRate:   outRate,
+		outLayout: outLayout,
+		outFmt:    outFmt,
+	}
+
+	if averr := C.swr_init(this.swrCtx); averr < 0 {
+		Release(this)
+		return nil, errors.New(fmt.Sprintf("unable to initialize swr context: %s", AvError(int(averr))))
+	}
+
+	return this, nil
+}
+
 func (this *SwrCtx) Free() {
 	C.swr_free(&this.swrCtx)
 }
@@ -62,3 +104,55 @@ func (this *SwrCtx) Convert(input *Frame) *Frame {
 
 	return dstFrame
 }
+
+// Resamples input into new frame, for contexts created by NewSwrCtxWithParams.
+// Nil input drains samples, buffered by resampler; io.EOF is returned when nothing is left.
+func (this *SwrCtx) ConvertFrame(input *Frame) (*Frame, error) {
+	var dstSamples int
+
+	if this.outRate == 0 || this.inRate == 0 {
+		return nil, errors.New("swr context is created without parameters, use Convert instead")
+	}
+
+	if input == nil {
+		dstSamples = int(C.swr_get_delay(this.swrCtx, C.int64_t(this.outRate)))
+		if dstSamples <= 0 {
+			return nil, io.EOF
+		}
+	} else {
+		delay := C.swr_get_delay(this.swrCtx, C.int64_t(this.inRate))
+		dstSamples = int(C.av_rescale_rnd(delay+C.int64_t(input.NbSamples()), C.int64_t(this.outRate), C.int64_t(this.inRate), C.AV_ROUND_UP))
+	}
+
+	channels := int(C.av_get_channel_layout_nb_channels(C.uint64_t(this.outLayout)))
+
+	dstFrame, err := NewAudioFrame(this.outFmt, channels, dstSamples)
+	if err != nil {
+		return nil, err
+	}
+
+	dstFrame.SetChannelLayout(this.outLayout).SetChannels(channels)
+	dstFrame.avFrame.sample_rate = C.int(this.outRate)
+
+	var ret int
+
+	if input == nil {
+		ret = int(C.gmf_sw_resample_flush(this.swrCtx, dstFrame.avFrame))
+	} else {
+		ret = int(C.gmf_sw_resample(this.swrCtx, dstFrame.avFrame, input.avFrame))
+	}
+
+	if ret < 0 {
+		Release(dstFrame)
+		return nil, errors.New(fmt.Sprintf("unable to convert samples: %s", AvError(ret)))
+	}
+
+	if input == nil && ret == 0 {
+		Release(dstFrame)
+		return nil, io.EOF
+	}
+
+	dstFrame.SetNbSamples(ret)
+
+	return dstFrame, nil
+}
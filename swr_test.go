@@ -24,3 +24,34 @@ func TestSwrInit(t *testing.T) {
 
 	log.Println("Swr context is createad")
 }
+
+func TestSwrCtxWithParams(t *testing.T) {
+	// stereo to mono
+	swrCtx, err := NewSwrCtxWithParams(3, 4, 48000, 16000, AV_SAMPLE_FMT_S16, AV_SAMPLE_FMT_S16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(swrCtx)
+
+	frame, err := NewAudioFrame(AV_SAMPLE_FMT_S16, 2, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(frame)
+
+	out, err := swrCtx.ConvertFrame(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.Println(out.NbSamples(), "samples converted")
+	Release(out)
+
+	for {
+		out, err := swrCtx.ConvertFrame(nil)
+		if err != nil {
+			break
+		}
+		Release(out)
+	}
+}
@@ -2,14 +2,30 @@ package gmf
 
 /*
 
-#cgo pkg-config: libswscale
+#cgo pkg-config: libswscale libavutil
 
 #include "libswscale/swscale.h"
+#include "libavutil/frame.h"
+
+static int gmf_sws_scale_all(struct SwsContext *ctx, AVFrame **src, AVFrame **dst, int n) {
+	int i;
+
+	for (i = 0; i < n; i++) {
+		if (sws_scale(ctx, (const uint8_t * const *)src[i]->data, src[i]->linesize, 0, src[i]->height, dst[i]->data, dst[i]->linesize) < 0) {
+			break;
+		}
+	}
+
+	return i;
+}
 
 */
 import "C"
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"unsafe"
 )
 
@@ -28,33 +44,101 @@ var (
 )
 
 type SwsCtx struct {
-	swsCtx *C.struct_SwsContext
+	swsCtx    *C.struct_SwsContext
+	srcWidth  int
+	srcHeight int
+	srcPixFmt int32
+	dstWidth  int
+	dstHeight int
+	dstPixFmt int32
+	flags     int
+	copyProps bool
+	mu        sync.Mutex
+
+	// frame pointers passed to C by ScaleAllInto, reused between calls
+	srcScratch []*C.struct_AVFrame
+	dstScratch []*C.struct_AVFrame
+
 	CgoMemoryManage
 }
 
 func NewSwsCtx(src *CodecCtx, dst *CodecCtx, method int) *SwsCtx {
-	ctx := C.sws_getContext(C.int(src.Width()), C.int(src.Height()), src.PixFmt(), C.int(dst.Width()), C.int(dst.Height()), dst.PixFmt(), C.int(method), nil, nil, nil)
+	return newSwsCtx(src.Width(), src.Height(), src.PixFmt(), dst.Width(), dst.Height(), dst.PixFmt(), method)
+}
+
+func NewPicSwsCtx(srcWidth int, srcHeight int, srcPixFmt int32, dst *CodecCtx, method int) *SwsCtx {
+	return newSwsCtx(srcWidth, srcHeight, srcPixFmt, dst.Width(), dst.Height(), dst.PixFmt(), method)
+}
+
+func newSwsCtx(srcWidth, srcHeight int, srcPixFmt int32, dstWidth, dstHeight int, dstPixFmt int32, method int) *SwsCtx {
+	ctx := C.sws_getContext(C.int(srcWidth), C.int(srcHeight), srcPixFmt, C.int(dstWidth), C.int(dstHeight), dstPixFmt, C.int(method), nil, nil, nil)
 
 	if ctx == nil {
 		return nil
 	}
 
-	return &SwsCtx{swsCtx: ctx}
+	return &SwsCtx{
+		swsCtx:    ctx,
+		srcWidth:  srcWidth,
+		srcHeight: srcHeight,
+		srcPixFmt: srcPixFmt,
+		dstWidth:  dstWidth,
+		dstHeight: dstHeight,
+		dstPixFmt: dstPixFmt,
+		flags:     method,
+	}
 }
 
-func NewPicSwsCtx(srcWidth int, srcHeight int, srcPixFmt int32, dst *CodecCtx, method int) *SwsCtx {
-	ctx := C.sws_getContext(C.int(srcWidth), C.int(srcHeight), srcPixFmt, C.int(dst.Width()), C.int(dst.Height()), dst.PixFmt(), C.int(method), nil, nil, nil)
+// Changes scaling algorithm, keeping dimensions and pixel formats.
+// It does nothing if flags are the same.
+func (this *SwsCtx) SetFlags(flags int) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
 
-	if ctx == nil {
+	if flags == this.flags && this.swsCtx != nil {
 		return nil
 	}
 
-	return &SwsCtx{swsCtx: ctx}
+	// sws_getCachedContext frees passed context, if it can't be reused
+	this.swsCtx = C.sws_getCachedContext(this.swsCtx, C.int(this.srcWidth), C.int(this.srcHeight), this.srcPixFmt, C.int(this.dstWidth), C.int(this.dstHeight), this.dstPixFmt, C.int(flags), nil, nil, nil)
+	if this.swsCtx == nil {
+		return errors.New("unable to reinitialize sws context")
+	}
+
+	this.flags = flags
+
+	return nil
 }
+
+func (this *SwsCtx) Flags() int {
+	return this.flags
+}
+
+// If set, scaling copies frame properties, including pts, from source frames to destination ones.
+func (this *SwsCtx) SetCopyProps(val bool) *SwsCtx {
+	this.copyProps = val
+	return this
+}
+
 func (this *SwsCtx) Free() {
+	if this.swsCtx == nil {
+		return
+	}
+
 	C.sws_freeContext(this.swsCtx)
+	this.swsCtx = nil
 }
+
+// Scales pixels of src into dst. Properties, e.g. pts, are copied only if SetCopyProps is set,
+// otherwise caller should propagate them or call dst.CopyProps.
 func (this *SwsCtx) Scale(src *Frame, dst *Frame) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if this.copyProps {
+		dst.CopyProps(src)
+	}
+
 	C.sws_scale(
 		this.swsCtx,
 		(**C.uint8_t)(unsafe.Pointer(&src.avFrame.data)),
@@ -64,3 +148,72 @@ func (this *SwsCtx) Scale(src *Frame, dst *Frame) {
 		(**C.uint8_t)(unsafe.Pointer(&dst.avFrame.data)),
 		(*_Ctype_int)(unsafe.Pointer(&dst.avFrame.linesize)))
 }
+
+// Scales all src frames into newly allocated frames in one cgo call.
+// Frames have reference counted buffers, so Release frees their data.
+func (this *SwsCtx) ScaleAll(src []*Frame) ([]*Frame, error) {
+	dst := make([]*Frame, len(src))
+
+	for i := range dst {
+		dst[i] = NewFrame().SetWidth(this.dstWidth).SetHeight(this.dstHeight).SetFormat(this.dstPixFmt)
+		dst[i].mediaType = AVMEDIA_TYPE_VIDEO
+
+		if err := dst[i].AllocBuffer(32); err != nil {
+			for _, f := range dst[:i+1] {
+				Release(f)
+			}
+			return nil, err
+		}
+	}
+
+	if err := this.ScaleAllInto(src, dst); err != nil {
+		for _, f := range dst {
+			Release(f)
+		}
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// Scales src frames into dst frames, which should be allocated by caller,
+// e.g. reused from previous call. len(dst) should be >= len(src).
+func (this *SwsCtx) ScaleAllInto(src, dst []*Frame) error {
+	if len(dst) < len(src) {
+		return errors.New(fmt.Sprintf("not enough destination frames: %d, expected %d", len(dst), len(src)))
+	}
+
+	if len(src) == 0 {
+		return nil
+	}
+
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if cap(this.srcScratch) < len(src) {
+		this.srcScratch = make([]*C.struct_AVFrame, len(src))
+		this.dstScratch = make([]*C.struct_AVFrame, len(src))
+	}
+
+	srcFrames := this.srcScratch[:len(src)]
+	dstFrames := this.dstScratch[:len(src)]
+
+	for i := range src {
+		srcFrames[i] = src[i].avFrame
+		dstFrames[i] = dst[i].avFrame
+	}
+
+	if n := int(C.gmf_sws_scale_all(this.swsCtx, &srcFrames[0], &dstFrames[0], C.int(len(src)))); n != len(src) {
+		return errors.New(fmt.Sprintf("unable to scale frame #%d", n))
+	}
+
+	if this.copyProps {
+		for i := range src {
+			if err := dst[i].CopyProps(src[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -63,9 +63,157 @@ func TestScale(t *testing.T) {
 
 		swsCtx.Scale(frame, dstFrame)
 
+		if err := swsCtx.SetFlags(SWS_LANCZOS); err != nil {
+			t.Fatal(err)
+		}
+
+		swsCtx.Scale(frame, dstFrame)
+
 		Release(frame)
 		break
 	}
 
 	log.Println("Swscale is OK")
 }
+
+func TestScaleAll(t *testing.T) {
+	codec, err := FindEncoder("mpeg4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcCodecCtx := NewCodecCtx(codec)
+	defer Release(srcCodecCtx)
+	srcCodecCtx.SetWidth(640).SetHeight(480).SetPixFmt(AV_PIX_FMT_YUV420P)
+
+	dstCodecCtx := NewCodecCtx(codec)
+	defer Release(dstCodecCtx)
+	dstCodecCtx.SetWidth(320).SetHeight(200).SetPixFmt(AV_PIX_FMT_YUV420P)
+
+	swsCtx := NewSwsCtx(srcCodecCtx, dstCodecCtx, SWS_BICUBIC)
+	defer Release(swsCtx)
+
+	src := make([]*Frame, 0)
+	for frame := range GenSyntVideoNewFrame(640, 480, AV_PIX_FMT_YUV420P) {
+		src = append(src, frame)
+	}
+
+	dst, err := swsCtx.ScaleAll(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dst) != len(src) {
+		t.Fatalf("Expected %d frames, %d got\n", len(src), len(dst))
+	}
+
+	if err := swsCtx.ScaleAllInto(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range src {
+		Release(src[i])
+		Release(dst[i])
+	}
+
+	log.Println(len(dst), "frames scaled")
+}
+
+func TestScaleCopyProps(t *testing.T) {
+	swsCtx := newSwsCtx(64, 48, AV_PIX_FMT_YUV420P, 32, 24, AV_PIX_FMT_YUV420P, SWS_BILINEAR)
+	defer Release(swsCtx)
+
+	src := NewFrame().SetWidth(64).SetHeight(48).SetFormat(AV_PIX_FMT_YUV420P)
+	defer Release(src)
+
+	dst := NewFrame().SetWidth(32).SetHeight(24).SetFormat(AV_PIX_FMT_YUV420P)
+	defer Release(dst)
+
+	if err := src.AllocBuffer(32); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dst.AllocBuffer(32); err != nil {
+		t.Fatal(err)
+	}
+
+	src.SetPts(42)
+
+	swsCtx.Scale(src, dst)
+
+	if dst.Pts() == 42 {
+		t.Fatal("Expected pts not to be copied by default")
+	}
+
+	swsCtx.SetCopyProps(true).Scale(src, dst)
+
+	if dst.Pts() != 42 {
+		t.Fatalf("Expected pts 42, %d got\n", dst.Pts())
+	}
+
+	if dst.Width() != 32 || dst.Height() != 24 {
+		t.Fatalf("Expected dimension 32x24 to be kept, %dx%d got\n", dst.Width(), dst.Height())
+	}
+}
+
+func BenchmarkScale(b *testing.B) {
+	swsCtx := newSwsCtx(640, 480, AV_PIX_FMT_YUV420P, 320, 200, AV_PIX_FMT_YUV420P, SWS_BILINEAR)
+	defer Release(swsCtx)
+
+	src := make([]*Frame, 0)
+	for frame := range GenSyntVideoNewFrame(640, 480, AV_PIX_FMT_YUV420P) {
+		src = append(src, frame)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, f := range src {
+			dst := NewFrame().SetWidth(320).SetHeight(200).SetFormat(AV_PIX_FMT_YUV420P)
+			if err := dst.AllocBuffer(32); err != nil {
+				b.Fatal(err)
+			}
+
+			swsCtx.Scale(f, dst)
+			Release(dst)
+		}
+	}
+
+	b.StopTimer()
+
+	for _, f := range src {
+		Release(f)
+	}
+}
+
+func BenchmarkScaleAllInto(b *testing.B) {
+	swsCtx := newSwsCtx(640, 480, AV_PIX_FMT_YUV420P, 320, 200, AV_PIX_FMT_YUV420P, SWS_BILINEAR)
+	defer Release(swsCtx)
+
+	src := make([]*Frame, 0)
+	for frame := range GenSyntVideoNewFrame(640, 480, AV_PIX_FMT_YUV420P) {
+		src = append(src, frame)
+	}
+
+	dst, err := swsCtx.ScaleAll(src)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := swsCtx.ScaleAllInto(src, dst); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.StopTimer()
+
+	for i := range src {
+		Release(src[i])
+		Release(dst[i])
+	}
+}
@@ -0,0 +1,199 @@
+package gmf
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"time"
+)
+
+// Returns frame at position at from the first video stream of the file, scaled to width x height.
+// If one of dimensions is zero, it's calculated to keep aspect ratio, if both - source size is used.
+func ExtractThumbnail(path string, at time.Duration, width, height int) (image.Image, error) {
+	th, err := newThumbnailer(path, width, height)
+	if err != nil {
+		return nil, err
+	}
+	defer Release(th)
+
+	return th.thumbnail(at)
+}
+
+// Returns cols x rows grid of thumbnails of thumbW x thumbH size, taken at evenly spaced positions of the file.
+func GenerateSpriteSheet(path string, cols, rows int, thumbW, thumbH int) (image.Image, error) {
+	if cols <= 0 || rows <= 0 {
+		return nil, errors.New("invalid sprite sheet grid size")
+	}
+
+	th, err := newThumbnailer(path, thumbW, thumbH)
+	if err != nil {
+		return nil, err
+	}
+	defer Release(th)
+
+	duration := th.ctx.Duration()
+	if duration <= 0 {
+		return nil, errors.New("unknown input duration")
+	}
+
+	n := cols * rows
+	sheet := image.NewRGBA(image.Rect(0, 0, cols*th.width, rows*th.height))
+
+	for i := 0; i < n; i++ {
+		// middle of i-th interval, so the last one doesn't hit the end of file
+		at := duration * time.Duration(2*i+1) / time.Duration(2*n)
+
+		img, err := th.thumbnail(at)
+		if err != nil {
+			return nil, err
+		}
+
+		x, y := (i%cols)*th.width, (i/cols)*th.height
+		draw.Draw(sheet, image.Rect(x, y, x+th.width, y+th.height), img, img.Bounds().Min, draw.Src)
+	}
+
+	return sheet, nil
+}
+
+type thumbnailer struct {
+	ctx    *FmtCtx
+	ist    *Stream
+	cc     *CodecCtx
+	sws    *SwsCtx
+	width  int
+	height int
+	CgoMemoryManage
+}
+
+func newThumbnailer(path string, width, height int) (*thumbnailer, error) {
+	ctx, err := NewInputCtx(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ist, cc, err := ctx.GetBestStreamWithDecoder(AVMEDIA_TYPE_VIDEO)
+	if err != nil {
+		ctx.CloseInputAndRelease()
+		return nil, err
+	}
+
+	srcW, srcH := cc.Width(), cc.Height()
+	if srcW <= 0 || srcH <= 0 {
+		Release(cc)
+		ctx.CloseInputAndRelease()
+		return nil, errors.New("unknown video dimensions")
+	}
+
+	switch {
+	case width <= 0 && height <= 0:
+		width, height = srcW, srcH
+	case width <= 0:
+		width = srcW * height / srcH
+	case height <= 0:
+		height = srcH * width / srcW
+	}
+
+	sws := newSwsCtx(srcW, srcH, cc.PixFmt(), width, height, AV_PIX_FMT_RGBA, SWS_BICUBIC)
+	if sws == nil {
+		Release(cc)
+		ctx.CloseInputAndRelease()
+		return nil, errors.New("unable to create scaling context")
+	}
+
+	return &thumbnailer{ctx: ctx, ist: ist, cc: cc, sws: sws, width: width, height: height}, nil
+}
+
+func (this *thumbnailer) thumbnail(at time.Duration) (image.Image, error) {
+	if err := this.ctx.SeekFrameAt(at, this.ist.Index()); err != nil {
+		return nil, err
+	}
+
+	target := RescaleQ(int64(at/time.Microsecond), AV_TIME_BASE_Q, this.ist.TimeBase())
+	if start := this.ist.StartTime(); !IsNoPts(start) {
+		target += start
+	}
+
+	frame, err := this.decodeTo(target)
+	if err != nil {
+		return nil, err
+	}
+	defer Release(frame)
+
+	dst := NewFrame().SetWidth(this.width).SetHeight(this.height).SetFormat(AV_PIX_FMT_RGBA)
+	defer Release(dst)
+
+	if err := dst.AllocBuffer(32); err != nil {
+		return nil, err
+	}
+
+	this.sws.Scale(frame, dst)
+
+	return dst.ToImage()
+}
+
+// Decodes forward from current position and returns the first frame with timestamp >= ts
+// or the last decoded one, if the end of stream is reached.
+func (this *thumbnailer) decodeTo(ts int64) (*Frame, error) {
+	var (
+		result *Frame
+		frames []*Frame
+		err    error
+		eof    bool
+	)
+
+	for !eof {
+		p := this.ctx.GetNextPacket()
+
+		if p == nil {
+			if err := this.ctx.Err(); err != nil {
+				releaseFrame(result)
+				return nil, err
+			}
+
+			frames, err = this.cc.Decode(nil)
+			eof = true
+		} else if p.StreamIndex() == this.ist.Index() {
+			frames, err = this.cc.Decode(p)
+			Release(p)
+		} else {
+			Release(p)
+			continue
+		}
+
+		if err != nil && err != io.EOF {
+			releaseFrame(result)
+			return nil, err
+		}
+
+		for i, f := range frames {
+			releaseFrame(result)
+			result = f
+
+			if pts := f.BestEffortTimestamp(); !IsNoPts(pts) && pts >= ts {
+				for _, rest := range frames[i+1:] {
+					Release(rest)
+				}
+				return result, nil
+			}
+		}
+	}
+
+	if result == nil {
+		return nil, errors.New("no frame decoded")
+	}
+
+	return result, nil
+}
+
+func releaseFrame(f *Frame) {
+	if f != nil {
+		Release(f)
+	}
+}
+
+func (this *thumbnailer) Free() {
+	Release(this.sws)
+	Release(this.cc)
+	this.ctx.CloseInputAndRelease()
+}
@@ -0,0 +1,33 @@
+package gmf
+
+import (
+	"log"
+	"testing"
+	"time"
+)
+
+func TestExtractThumbnail(t *testing.T) {
+	img, err := ExtractThumbnail(inputSampleFilename, 500*time.Millisecond, 160, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b := img.Bounds(); b.Dx() != 160 || b.Dy() != 100 {
+		t.Fatalf("Expected 160x100 thumbnail, %dx%d got\n", b.Dx(), b.Dy())
+	}
+
+	log.Println("Thumbnail has been extracted")
+}
+
+func TestGenerateSpriteSheet(t *testing.T) {
+	img, err := GenerateSpriteSheet(inputSampleFilename, 4, 2, 80, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b := img.Bounds(); b.Dx() != 320 || b.Dy() != 100 {
+		t.Fatalf("Expected 320x100 sprite sheet, %dx%d got\n", b.Dx(), b.Dy())
+	}
+
+	log.Println("Sprite sheet has been generated")
+}
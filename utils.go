@@ -4,9 +4,13 @@ package gmf
 
 #cgo pkg-config: libavcodec libavutil
 
+#include <errno.h>
+#include <stdlib.h>
+
 #include "libavutil/avutil.h"
 #include "libavutil/error.h"
 #include "libavutil/mathematics.h"
+#include "libavutil/pixdesc.h"
 #include "libavutil/rational.h"
 #include "libavutil/samplefmt.h"
 
@@ -16,6 +20,7 @@ import "C"
 import (
 	"bytes"
 	"errors"
+	"time"
 	"unsafe"
 )
 
@@ -34,9 +39,33 @@ func (this AVRational) AVR() AVR {
 	return AVR{Num: int(this.num), Den: int(this.den)}
 }
 
+func (this AVR) Float64() float64 {
+	return float64(C.av_q2d(C.struct_AVRational(this.AVRational())))
+}
+
+// Returns 1/r.
+func (this AVR) Invert() AVR {
+	return AVR{Num: this.Den, Den: this.Num}
+}
+
+func (this AVR) Mul(o AVR) AVR {
+	return AVRational(C.av_mul_q(C.struct_AVRational(this.AVRational()), C.struct_AVRational(o.AVRational()))).AVR()
+}
+
+func (this AVR) Add(o AVR) AVR {
+	return AVRational(C.av_add_q(C.struct_AVRational(this.AVRational()), C.struct_AVRational(o.AVRational()))).AVR()
+}
+
+// Rescales val from r time base to dst, the same as RescaleQ.
+func (this AVR) RescaleQ(val int64, dst AVR) int64 {
+	return RescaleQ(val, this.AVRational(), dst.AVRational())
+}
+
 var (
 	AV_TIME_BASE   int        = C.AV_TIME_BASE
 	AV_TIME_BASE_Q AVRational = AVRational{1, C.int(AV_TIME_BASE)}
+	AVERROR_EOF    int        = C.AVERROR_EOF
+	AVERROR_EAGAIN int        = -int(C.EAGAIN)
 )
 
 func AvError(averr int) error {
@@ -52,6 +81,20 @@ func RescaleQ(a int64, encBase AVRational, stBase AVRational) int64 {
 	return int64(C.av_rescale_q(C.int64_t(a), C.struct_AVRational(encBase), C.struct_AVRational(stBase)))
 }
 
+// Reports whether ts is unset, i.e. equals to AV_NOPTS_VALUE.
+func IsNoPts(ts int64) bool {
+	return ts == AV_NOPTS_VALUE
+}
+
+// Converts timestamp in tb units into time.Duration. AV_NOPTS_VALUE is converted to 0.
+func toDuration(ts int64, tb AVRational) time.Duration {
+	if IsNoPts(ts) {
+		return 0
+	}
+
+	return time.Duration(RescaleQ(ts, tb, AVR{1, int(time.Second)}.AVRational()))
+}
+
 func CompareTimeStamp(aTimestamp int, aTimebase AVRational, bTimestamp int, bTimebase AVRational) int {
 	return int(C.av_compare_ts(C.int64_t(aTimestamp), C.struct_AVRational(aTimebase),
 		C.int64_t(bTimestamp), C.struct_AVRational(bTimebase)))
@@ -68,6 +111,32 @@ func GetSampleFmtName(fmt int32) string {
 	return C.GoString(C.av_get_sample_fmt_name(fmt))
 }
 
+// Returns name of pixel format, e.g. "yuv420p", or empty string if it's unknown.
+func PixelFormatName(fmt int32) string {
+	return C.GoString(C.av_get_pix_fmt_name(fmt))
+}
+
+// Returns pixel format by its name, -1 (AV_PIX_FMT_NONE) if it's not found.
+func PixelFormatByName(name string) int32 {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	return int32(C.av_get_pix_fmt(cname))
+}
+
+// Returns name of sample format, e.g. "s16", or empty string if it's unknown.
+func SampleFormatName(fmt int32) string {
+	return GetSampleFmtName(fmt)
+}
+
+// Returns sample format by its name, -1 (AV_SAMPLE_FMT_NONE) if it's not found.
+func SampleFormatByName(name string) int32 {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	return int32(C.av_get_sample_fmt(cname))
+}
+
 // Synthetic video generator. It produces 25 iteratable frames.
 // Used for tests.
 func GenSyntVideoNewFrame(w, h int, fmt int32) chan *Frame {
@@ -9,3 +9,55 @@ func TestAvError(t *testing.T) {
 		t.Fatalf("Expected error is 'No such file or directory', '%s' got\n", err.Error())
 	}
 }
+
+func TestAVR(t *testing.T) {
+	r := AVR{1, 25}
+
+	if r.Float64() != 0.04 {
+		t.Fatalf("Expected 0.04, %v got\n", r.Float64())
+	}
+
+	if inv := r.Invert(); inv.Num != 25 || inv.Den != 1 {
+		t.Fatalf("Expected 25/1, %v got\n", inv)
+	}
+
+	if m := r.Mul(AVR{5, 2}); m.Num != 1 || m.Den != 10 {
+		t.Fatalf("Expected 1/10, %v got\n", m)
+	}
+
+	if a := r.Add(AVR{1, 50}); a.Num != 3 || a.Den != 50 {
+		t.Fatalf("Expected 3/50, %v got\n", a)
+	}
+
+	if ts := r.RescaleQ(50, AVR{1, 1000}); ts != 2000 {
+		t.Fatalf("Expected 2000, %d got\n", ts)
+	}
+}
+
+func TestIsNoPts(t *testing.T) {
+	if !IsNoPts(AV_NOPTS_VALUE) || IsNoPts(0) {
+		t.Fatal("Expected only AV_NOPTS_VALUE to be unset timestamp")
+	}
+}
+
+func TestFormatNames(t *testing.T) {
+	if name := PixelFormatName(AV_PIX_FMT_YUV420P); name != "yuv420p" {
+		t.Fatalf("Expected 'yuv420p', '%s' got\n", name)
+	}
+
+	if pf := PixelFormatByName("rgba"); pf != AV_PIX_FMT_RGBA {
+		t.Fatalf("Expected %d, %d got\n", AV_PIX_FMT_RGBA, pf)
+	}
+
+	if pf := PixelFormatByName("unknown"); pf != -1 {
+		t.Fatalf("Expected -1, %d got\n", pf)
+	}
+
+	if name := SampleFormatName(AV_SAMPLE_FMT_S16); name != "s16" {
+		t.Fatalf("Expected 's16', '%s' got\n", name)
+	}
+
+	if sf := SampleFormatByName("s16p"); sf != AV_SAMPLE_FMT_S16P {
+		t.Fatalf("Expected %d, %d got\n", AV_SAMPLE_FMT_S16P, sf)
+	}
+}